@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mehmonov/movies-crud/config"
+	"github.com/mehmonov/movies-crud/internal/db"
+)
+
+const commandUsage = `Usage: %s [flags] [command]
+
+Without a command the API server is started.
+
+Commands:
+  import  Import movies from a CSV or NDJSON file
+  export  Export movies as CSV, NDJSON or JSON
+  migrate Apply, revert or list database migrations
+  config  Print the configuration with secrets redacted
+
+Settings are read from the flags below, then from the environment, then
+from the -config file, and otherwise take the defaults shown.
+
+Flags:
+`
+
+// runCommand runs a maintenance command instead of the server.
+func runCommand(cfg *config.Config, name string, args []string) {
+	var err error
+	switch name {
+	case "import":
+		err = runImport(cfg, args)
+	case "export":
+		err = runExport(cfg, args)
+	case "migrate":
+		err = runMigrate(cfg, args)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q, see %s -h\n", name, os.Args[0])
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// openDatabase connects to the configured database. Database warnings go
+// to standard error, leaving standard output to the command.
+func openDatabase(cfg *config.Config) (*gorm.DB, error) {
+	database, err := db.NewDatabase(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to the database: %w", err)
+	}
+
+	database.Logger = commandLogger()
+	return database, nil
+}
+
+// commandLogger logs database warnings to standard error.
+func commandLogger() logger.Interface {
+	return logger.New(log.New(os.Stderr, "", log.LstdFlags), logger.Config{
+		SlowThreshold: 200 * time.Millisecond,
+		LogLevel:      logger.Warn,
+	})
+}
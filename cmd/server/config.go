@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mehmonov/movies-crud/config"
+)
+
+// runConfig prints the configuration the server would run with, as YAML
+// with secrets redacted, followed by the problems Load found in it, if
+// any.
+func runConfig(cfg *config.Config, problems error, args []string) {
+	if len(args) != 1 || args[0] != "print" {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] config print\n", os.Args[0])
+		os.Exit(2)
+	}
+	if err := cfg.Print(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+	if problems != nil {
+		log.Fatal(problems)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/mehmonov/movies-crud/config"
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/repository"
+	"github.com/mehmonov/movies-crud/internal/services"
+)
+
+// runExport writes the movies matching the filters, like GET
+// /movies/export, to standard output or a file.
+func runExport(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", models.CatalogFormatCSV, "export format, csv, ndjson or json")
+	output := flags.String("o", "-", "output file, - for standard output")
+	compress := flags.Bool("gzip", false, "compress the export with gzip (default: when the output file ends in .gz)")
+	var filters models.MovieListQuery
+	flags.StringVar(&filters.Title, "title", "", "only movies whose title contains this, ignoring case")
+	flags.StringVar(&filters.Director, "director", "", "only movies whose director contains this, ignoring case")
+	flags.IntVar(&filters.YearFrom, "year-from", 0, "earliest release year")
+	flags.IntVar(&filters.YearTo, "year-to", 0, "latest release year")
+	genres := flags.String("genre", "", "only movies in any of these comma separated genre IDs")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s export [flags]\n\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	for _, id := range strings.Split(*genres, ",") {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid genre ID %q", id)
+		}
+		filters.GenreIDs = append(filters.GenreIDs, uint(n))
+	}
+
+	database, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	movieService := services.NewMovieService(database, repository.NewGormMovieRepository(database))
+
+	out := os.Stdout
+	if *output != "-" {
+		if out, err = os.Create(*output); err != nil {
+			return err
+		}
+		defer out.Close()
+		if strings.HasSuffix(*output, ".gz") {
+			*compress = true
+		}
+	}
+
+	buffered := bufio.NewWriter(out)
+	var w io.Writer = buffered
+	var gz *gzip.Writer
+	if *compress {
+		gz = gzip.NewWriter(buffered)
+		w = gz
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	count, err := movieService.ExportMovies(ctx, w, *format, &filters)
+	if err != nil {
+		return err
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	if out != os.Stdout {
+		if err := out.Close(); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Exported %d movies", count)
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/mehmonov/movies-crud/config"
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/repository"
+	"github.com/mehmonov/movies-crud/internal/services"
+)
+
+// runImport imports movies from a CSV or NDJSON file, or standard input
+// when the file is "-", like POST /movies/import. It fails when any row
+// could not be imported.
+func runImport(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "file format, csv or ndjson (default: from the file extension)")
+	dryRun := flags.Bool("dry-run", false, "validate and report without saving")
+	batchSize := flags.Int("batch-size", services.DefaultImportBatchSize, "rows per transaction")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s import [flags] FILE\n\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	path := flags.Arg(0)
+
+	if *format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			*format = models.CatalogFormatCSV
+		case ".ndjson", ".jsonl":
+			*format = models.CatalogFormatNDJSON
+		default:
+			return errors.New("cannot tell the format from the file name, set -format")
+		}
+	}
+
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	database, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	movieService := services.NewMovieService(database, repository.NewGormMovieRepository(database))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := movieService.ImportMovies(ctx, r, *format, *dryRun, *batchSize, 0)
+	if report != nil {
+		printImportReport(report, *asJSON)
+	}
+	if err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", report.Failed, report.Rows)
+	}
+	return nil
+}
+
+func printImportReport(report *models.MovieImportReport, asJSON bool) {
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+		return
+	}
+
+	for _, rowErr := range report.Errors {
+		fmt.Printf("line %d: %s\n", rowErr.Line, rowErr.Message)
+	}
+	if report.ErrorsTruncated {
+		fmt.Printf("(only the first %d errors are listed)\n", len(report.Errors))
+	}
+
+	verb := "Imported"
+	if report.DryRun {
+		verb = "Dry run:"
+	}
+	fmt.Printf("%s %d rows: %d created, %d updated, %d failed\n",
+		verb, report.Rows, report.Created, report.Updated, report.Failed)
+}
@@ -1,16 +1,28 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net"
+	"net/http"
+	"os"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/fx"
+	"gorm.io/gorm"
 
 	"github.com/mehmonov/movies-crud/config"
 	_ "github.com/mehmonov/movies-crud/docs" 
 	"github.com/mehmonov/movies-crud/internal/api/routes"
 	"github.com/mehmonov/movies-crud/internal/db"
+	"github.com/mehmonov/movies-crud/internal/repository"
 	"github.com/mehmonov/movies-crud/internal/services"
+	"github.com/mehmonov/movies-crud/internal/storage"
+	"github.com/mehmonov/movies-crud/pkg/auth"
 )
 
 // @title           Movies CRUD API
@@ -32,21 +44,207 @@ import (
 // @in header
 // @name Authorization
 func main() {
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), commandUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	cfg, err := config.Load(flags, os.Args[1:])
+	if flags.Arg(0) == "config" {
+		runConfig(cfg, err, flags.Args()[1:])
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if flags.NArg() > 0 {
+		runCommand(cfg, flags.Arg(0), flags.Args()[1:])
+		return
+	}
+
 	app := fx.New(
+		fx.Supply(cfg),
 		fx.Provide(
-			config.NewConfig,
-			db.NewDatabase,
+			newDatabase,
+			fx.Annotate(
+				repository.NewGormMovieRepository,
+				fx.As(new(repository.MovieRepository)),
+			),
+			fx.Annotate(
+				repository.NewGormUserRepository,
+				fx.As(new(repository.UserRepository)),
+			),
+			newJWTService,
 			services.NewMovieService,
 			services.NewUserService,
+			services.NewTokenService,
+			services.NewGenreService,
+			services.NewPersonService,
+			services.NewCreditService,
+			services.NewReviewService,
+			services.NewWatchlistService,
+			services.NewHistoryService,
+			newMovieSearcher,
+			newBlobStore,
+			newURLSigner,
+			services.NewHLSService,
+			services.NewImageService,
+			services.NewSubtitleService,
+			services.NewTrashService,
+			services.NewMediaService,
 			routes.NewRouter,
 		),
-		fx.Invoke(startServer),
+		fx.Invoke(bootstrapAdmin, startHLSPackager, startTrashPurger, startServer),
+		// Stop hooks run in reverse: the server drains its requests within
+		// ShutdownTimeout, then the workers and the database get the
+		// default timeout of fx to stop.
+		fx.StopTimeout(cfg.HTTP.ShutdownTimeout+fx.DefaultTimeout),
 	)
 
 	app.Run()
 }
 
-func startServer(router *gin.Engine, cfg *config.Config) {
-	log.Printf("Starting server on :%s", cfg.ServerPort)
-	router.Run(":" + cfg.ServerPort)
+// newDatabase connects to the database and closes it when the application
+// stops, after everything that uses it.
+func newDatabase(lc fx.Lifecycle, cfg *config.Config) (*gorm.DB, error) {
+	database, err := db.NewDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	lc.Append(fx.Hook{OnStop: func(context.Context) error {
+		return db.Close(database)
+	}})
+	return database, nil
+}
+
+// newJWTService signs with the key in JWT_SIGNING_KEY_FILE when it is set,
+// and with the shared JWT_SECRET otherwise. Config.Validate refuses the
+// default secret outside development.
+func newJWTService(cfg *config.Config) (*auth.JWTService, error) {
+	if cfg.Auth.JWTSigningKeyFile == "" {
+		return auth.NewJWTService(cfg.Auth.JWTSecret, cfg.Auth.AccessTokenTTL), nil
+	}
+
+	signingKey, err := auth.LoadKeyFile(cfg.Auth.JWTSigningKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading JWT signing key: %w", err)
+	}
+
+	var verificationKeys []*auth.Key
+	for _, path := range cfg.Auth.JWTVerificationKeyFiles {
+		key, err := auth.LoadKeyFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading JWT verification key: %w", err)
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+
+	log.Printf("Signing tokens with %s key %s", signingKey.Method.Alg(), signingKey.ID)
+	return auth.NewJWTServiceWithKeys(signingKey, verificationKeys, cfg.Auth.AccessTokenTTL)
+}
+
+// newMovieSearcher uses the full-text indexes of PostgreSQL, which SQLite
+// databases do not have.
+func newMovieSearcher(cfg *config.Config, database *gorm.DB) services.MovieSearcher {
+	if cfg.DB.Driver == config.DBDriverPostgres {
+		return services.NewPostgresMovieSearcher(database)
+	}
+	return services.NewScanMovieSearcher(database)
+}
+
+// newBlobStore keeps uploaded files on local disk under UPLOAD_DIR.
+func newBlobStore(cfg *config.Config) (storage.BlobStore, error) {
+	return storage.NewLocalBlobStore(cfg.Storage.UploadDir)
+}
+
+// newURLSigner signs media stream URLs with MEDIA_URL_SECRET, or with a
+// random secret when it is not set.
+func newURLSigner(cfg *config.Config) (*auth.URLSigner, error) {
+	secret := []byte(cfg.Media.URLSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		if cfg.Media.RequireSignedURL {
+			log.Printf("MEDIA_URL_SECRET is not set, signed media URLs will not survive a restart")
+		}
+	}
+	return auth.NewURLSigner(secret, cfg.Media.URLTTL), nil
+}
+
+// bootstrapAdmin creates or promotes the first admin when ADMIN_USERNAME is
+// set and no admin exists yet.
+func bootstrapAdmin(cfg *config.Config, userService *services.UserService) error {
+	if cfg.Admin.Username == "" {
+		return nil
+	}
+	if err := userService.EnsureAdmin(cfg.Admin.Username, cfg.Admin.Password); err != nil {
+		return err
+	}
+	log.Printf("Admin account %q is ready", cfg.Admin.Username)
+	return nil
+}
+
+// startHLSPackager runs the background worker that packages uploaded MP4
+// files for HLS.
+func startHLSPackager(lc fx.Lifecycle, packager *services.HLSService) {
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error { return packager.Start() },
+		OnStop:  packager.Stop,
+	})
+}
+
+// startTrashPurger runs the job that purges movies deleted longer ago than
+// TRASH_RETENTION.
+func startTrashPurger(lc fx.Lifecycle, trash *services.TrashService) {
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			trash.Start()
+			return nil
+		},
+		OnStop: trash.Stop,
+	})
+}
+
+// startServer serves the API once everything else has started. On stop it
+// stops accepting connections and waits up to SHUTDOWN_TIMEOUT for requests
+// in flight, then closes the connections that are left. The application
+// stops when the server fails.
+func startServer(lc fx.Lifecycle, shutdowner fx.Shutdowner, router *gin.Engine, cfg *config.Config) {
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.HTTP.Port),
+		Handler:           router,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			listener, err := net.Listen("tcp", server.Addr)
+			if err != nil {
+				return err
+			}
+			log.Printf("Starting server on %s", server.Addr)
+			go func() {
+				if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+					log.Printf("Server failed: %v", err)
+					shutdowner.Shutdown(fx.ExitCode(1))
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			log.Printf("Shutting down server, waiting up to %s for requests in flight", cfg.HTTP.ShutdownTimeout)
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+			defer cancel()
+			if err := server.Shutdown(ctx); err != nil {
+				log.Printf("Requests still in flight after %s, closing their connections", cfg.HTTP.ShutdownTimeout)
+				return server.Close()
+			}
+			return nil
+		},
+	})
 }
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/mehmonov/movies-crud/config"
+	"github.com/mehmonov/movies-crud/internal/db"
+	"github.com/mehmonov/movies-crud/internal/db/migrations"
+)
+
+const migrateUsage = `Usage: %s migrate up|down [n]|status
+
+  up      Apply every migration that has not been applied yet
+  down    Revert the latest n applied migrations (default 1)
+  status  List the migrations and when they were applied
+`
+
+// runMigrate changes or shows the version of the database schema.
+func runMigrate(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), migrateUsage, os.Args[0])
+	}
+	flags.Parse(args)
+
+	steps := 1
+	switch {
+	case flags.NArg() == 1 && (flags.Arg(0) == "up" || flags.Arg(0) == "down" || flags.Arg(0) == "status"):
+	case flags.NArg() == 2 && flags.Arg(0) == "down":
+		n, err := strconv.Atoi(flags.Arg(1))
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number of migrations %q", flags.Arg(1))
+		}
+		steps = n
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	database, err := db.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connecting to the database: %w", err)
+	}
+	database.Logger = commandLogger()
+	migrator, err := migrations.New(database, cfg.DB.Driver)
+	if err != nil {
+		return err
+	}
+
+	switch flags.Arg(0) {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migrations\n", len(applied))
+	case "down":
+		reverted, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d migrations\n", len(reverted))
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.UTC().Format("2006-01-02 15:04:05 UTC")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	}
+	return nil
+}
@@ -1,34 +1,219 @@
 package config
 
 import (
-    "os"
+    "time"
 )
 
+const (
+    EnvDevelopment = "development"
+    EnvProduction  = "production"
+
+    DBDriverPostgres = "postgres"
+    DBDriverSQLite   = "sqlite"
+
+    // DefaultJWTSecret is the placeholder secret used when JWT_SECRET is not
+    // set. It is only accepted in development.
+    DefaultJWTSecret = "your-secret-key"
+)
+
+// Config is the configuration of the server and of its commands. It is
+// read by Load from, in increasing order of precedence, the defaults of
+// Default, a YAML or TOML file, environment variables and command line
+// flags.
+//
+// Each setting has a key, the path of yaml tags leading to it (e.g.
+// "db.pool.max_open_conns"), which names it in files and as a flag, and
+// most have an environment variable, its env tag. Settings tagged secret
+// are redacted by Print.
 type Config struct {
-    DBHost     string
-    DBPort     string
-    DBUser     string
-    DBPassword string
-    DBName     string
-    ServerPort string
-    JWTSecret  string
+    // Env is "development" or "production". Insecure defaults are refused
+    // outside development.
+    Env string `yaml:"env" env:"APP_ENV" usage:"development or production"`
+
+    DB      DBConfig      `yaml:"db"`
+    HTTP    HTTPConfig    `yaml:"http"`
+    Auth    AuthConfig    `yaml:"auth"`
+    Storage StorageConfig `yaml:"storage"`
+    Media   MediaConfig   `yaml:"media"`
+    Movies  MoviesConfig  `yaml:"movies"`
+    Admin   AdminConfig   `yaml:"admin"`
 }
 
-func NewConfig() *Config {
-    return &Config{
-        DBHost:     getEnv("DB_HOST", "localhost"),
-        DBPort:     getEnv("DB_PORT", "5432"),
-        DBUser:     getEnv("DB_USER", "postgres"),
-        DBPassword: getEnv("DB_PASSWORD", "1234"),
-        DBName:     getEnv("DB_NAME", "movies-crud"),
-        ServerPort: getEnv("SERVER_PORT", "8080"),
-        JWTSecret:  getEnv("JWT_SECRET", "your-secret-key"),
-    }
+type DBConfig struct {
+    // Driver is "postgres", which uses the connection settings below, or
+    // "sqlite", which keeps the database in the file Path, or in memory
+    // when it is ":memory:". SQLite needs no server and is meant for
+    // development and tests.
+    Driver   string `yaml:"driver" env:"DB_DRIVER" usage:"postgres or sqlite"`
+    Path     string `yaml:"path" env:"DB_PATH" usage:"SQLite database file, or :memory:"`
+    Host     string `yaml:"host" env:"DB_HOST" usage:"PostgreSQL host"`
+    Port     int    `yaml:"port" env:"DB_PORT" usage:"PostgreSQL port"`
+    User     string `yaml:"user" env:"DB_USER" usage:"PostgreSQL user"`
+    Password string `yaml:"password" env:"DB_PASSWORD" secret:"true" usage:"PostgreSQL password"`
+    Name     string `yaml:"name" env:"DB_NAME" usage:"PostgreSQL database"`
+
+    // SSLMode is the libpq sslmode of the PostgreSQL connection: disable,
+    // allow, prefer, require, verify-ca or verify-full. The verify modes
+    // check the server certificate against SSLRootCert, or the system roots
+    // when it is empty. SSLCert and SSLKey log in with a client certificate.
+    SSLMode     string `yaml:"ssl_mode" env:"DB_SSL_MODE" usage:"disable, allow, prefer, require, verify-ca or verify-full"`
+    SSLRootCert string `yaml:"ssl_root_cert" env:"DB_SSL_ROOT_CERT" usage:"PEM file of the CA that signed the server certificate"`
+    SSLCert     string `yaml:"ssl_cert" env:"DB_SSL_CERT" usage:"PEM client certificate"`
+    SSLKey      string `yaml:"ssl_key" env:"DB_SSL_KEY" usage:"PEM key of the client certificate"`
+
+    // ReplicaDSNs are the connection strings of PostgreSQL read replicas,
+    // e.g. "host=replica1 user=app password=... dbname=movies_crud
+    // sslmode=require". The movie listing and movie details are read from
+    // them; everything else, and every transaction, uses the primary.
+    ReplicaDSNs []string `yaml:"replica_dsns" env:"DB_REPLICA_DSNS" secret:"true" usage:"comma separated connection strings of read replicas"`
+
+    // ConnectRetryTimeout is how long to keep trying to connect at startup
+    // while the database is not ready, with growing pauses between the
+    // attempts. Zero gives up after the first attempt.
+    ConnectRetryTimeout time.Duration `yaml:"connect_retry_timeout" env:"DB_CONNECT_RETRY_TIMEOUT" usage:"time to keep retrying the first connection, 0 to try once"`
+
+    // MigrateOnStart applies the missing migrations when the server
+    // starts. When off, the server refuses to start until they have been
+    // applied with the migrate command.
+    MigrateOnStart bool `yaml:"migrate_on_start" env:"DB_MIGRATE_ON_START" usage:"apply missing migrations at startup"`
+
+    Pool DBPoolConfig `yaml:"pool"`
+}
+
+// DBPoolConfig sizes the pools of database connections, of the primary
+// and of each replica. Zero MaxOpenConns, ConnMaxLifetime and
+// ConnMaxIdleTime mean no limit.
+type DBPoolConfig struct {
+    MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" usage:"maximum open connections, 0 for no limit"`
+    MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" usage:"maximum idle connections kept open"`
+    ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" usage:"close connections older than this, 0 keeps them"`
+    ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" usage:"close connections idle longer than this, 0 keeps them"`
+}
+
+// HTTPConfig is the HTTP server. ReadHeaderTimeout and IdleTimeout limit
+// how long the server waits for the headers of a request and for the next
+// request on a kept-alive connection. ReadTimeout and WriteTimeout limit
+// reading a whole request and writing a whole response; they are off by
+// default since uploads and video streams can take as long as they need.
+// ShutdownTimeout is how long requests in flight get to finish when the
+// server stops.
+type HTTPConfig struct {
+    Port              int           `yaml:"port" env:"SERVER_PORT" usage:"port to listen on"`
+    ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" usage:"time to read the headers of a request"`
+    ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT" usage:"time to read a whole request, 0 for no limit"`
+    WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" usage:"time to write a whole response, 0 for no limit"`
+    IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" usage:"time to wait for the next request on a connection"`
+    ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" usage:"time requests in flight get to finish on shutdown"`
+}
+
+type AuthConfig struct {
+    JWTSecret string `yaml:"jwt_secret" env:"JWT_SECRET" secret:"true" usage:"secret that signs tokens with HS256"`
+
+    // JWTSigningKeyFile is a PEM file with an RSA or Ed25519 private key.
+    // When set, tokens are signed with it instead of JWTSecret, and the
+    // public keys of it and of JWTVerificationKeyFiles are published as a
+    // JWKS so that other services can verify tokens.
+    JWTSigningKeyFile       string   `yaml:"jwt_signing_key_file" env:"JWT_SIGNING_KEY_FILE" usage:"PEM private key that signs tokens instead of the secret"`
+    JWTVerificationKeyFiles []string `yaml:"jwt_verification_key_files" env:"JWT_VERIFICATION_KEY_FILES" usage:"comma separated PEM keys of tokens signed before a rotation"`
+
+    // AccessTokenTTL is how long an access token is valid. Clients renew it
+    // with a refresh token, which stays valid for RefreshTokenTTL unless it
+    // is used or revoked.
+    AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"ACCESS_TOKEN_TTL" usage:"lifetime of access tokens"`
+    RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL" usage:"lifetime of refresh tokens"`
 }
 
-func getEnv(key, defaultValue string) string {
-    if value, exists := os.LookupEnv(key); exists {
-        return value
+// StorageConfig is where uploads go. UploadDir holds one directory per
+// movie; the sizes are limits in bytes of a single video, poster or
+// backdrop, and subtitle file.
+type StorageConfig struct {
+    UploadDir       string `yaml:"upload_dir" env:"UPLOAD_DIR" usage:"directory of uploaded files"`
+    MaxUploadSize   int64  `yaml:"max_upload_size" env:"MAX_UPLOAD_SIZE" usage:"largest video upload in bytes"`
+    MaxImageSize    int64  `yaml:"max_image_size" env:"MAX_IMAGE_SIZE" usage:"largest image upload in bytes"`
+    MaxSubtitleSize int64  `yaml:"max_subtitle_size" env:"MAX_SUBTITLE_SIZE" usage:"largest subtitle upload in bytes"`
+}
+
+type MediaConfig struct {
+    // RequireSignedURL makes streaming a video require either a bearer
+    // token or a URL signed with URLSecret, valid for URLTTL. Players keep
+    // requesting ranges of a stream URL while they play, so URLTTL must
+    // outlast the longest video. Without a secret, a random one is used and
+    // signed URLs stop working when the server restarts.
+    RequireSignedURL bool          `yaml:"require_signed_url" env:"MEDIA_REQUIRE_SIGNED_URL" usage:"require a token or a signed URL to stream videos"`
+    URLSecret        string        `yaml:"url_secret" env:"MEDIA_URL_SECRET" secret:"true" usage:"secret that signs media URLs"`
+    URLTTL           time.Duration `yaml:"url_ttl" env:"MEDIA_URL_TTL" usage:"lifetime of signed media URLs, longer than any video"`
+
+    // HLSSegmentDuration is the target length of the segments MP4 uploads
+    // are split into for HLS. Segments start on keyframes, so they can be
+    // longer.
+    HLSSegmentDuration time.Duration `yaml:"hls_segment_duration" env:"HLS_SEGMENT_DURATION" usage:"target length of HLS segments"`
+}
+
+type MoviesConfig struct {
+    // RequireIfMatch makes updates and deletes of movies require an If-Match
+    // header with the movie's ETag, so that they cannot overwrite changes the
+    // client has not seen.
+    RequireIfMatch bool `yaml:"require_if_match" env:"REQUIRE_IF_MATCH" usage:"require If-Match to update or delete a movie"`
+
+    // TrashRetention is how long deleted movies stay in the trash before
+    // they are purged for good. Zero, the default, keeps them until purged
+    // by hand, so nothing is ever purged unless it is set.
+    TrashRetention time.Duration `yaml:"trash_retention" env:"TRASH_RETENTION" usage:"time deleted movies are kept, 0 keeps them"`
+}
+
+// AdminConfig bootstraps the first admin account; see
+// services.UserService.EnsureAdmin.
+type AdminConfig struct {
+    Username string `yaml:"username" env:"ADMIN_USERNAME" usage:"user to make an admin at startup"`
+    Password string `yaml:"password" env:"ADMIN_PASSWORD" secret:"true" usage:"password of the admin, which an existing user must already have to be promoted"`
+}
+
+// Default returns the configuration used for everything that is not set.
+// The database password and the secrets have no usable default.
+func Default() *Config {
+    return &Config{
+        Env: EnvProduction,
+
+        DB: DBConfig{
+            Driver:         DBDriverPostgres,
+            Path:           "movies.db",
+            Host:           "localhost",
+            Port:           5432,
+            User:           "postgres",
+            Name:           "movies-crud",
+            SSLMode:        "prefer",
+            MigrateOnStart: true,
+
+            ConnectRetryTimeout: 30 * time.Second,
+
+            Pool: DBPoolConfig{
+                MaxIdleConns: 2,
+            },
+        },
+
+        HTTP: HTTPConfig{
+            Port:              8080,
+            ReadHeaderTimeout: 10 * time.Second,
+            IdleTimeout:       2 * time.Minute,
+            ShutdownTimeout:   30 * time.Second,
+        },
+
+        Auth: AuthConfig{
+            JWTSecret:       DefaultJWTSecret,
+            AccessTokenTTL:  15 * time.Minute,
+            RefreshTokenTTL: 30 * 24 * time.Hour,
+        },
+
+        Storage: StorageConfig{
+            UploadDir:       "uploads",
+            MaxUploadSize:   5 << 30,
+            MaxImageSize:    20 << 20,
+            MaxSubtitleSize: 2 << 20,
+        },
+
+        Media: MediaConfig{
+            URLTTL:             4 * time.Hour,
+            HLSSegmentDuration: 6 * time.Second,
+        },
     }
-    return defaultValue
-}
\ No newline at end of file
+}
@@ -0,0 +1,277 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Problems lists everything wrong with a configuration.
+type Problems []string
+
+func (p Problems) Error() string {
+	return "invalid configuration:\n  " + strings.Join(p, "\n  ")
+}
+
+// setting is one leaf field of Config.
+type setting struct {
+	key    string
+	env    string
+	secret bool
+	usage  string
+	value  reflect.Value
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// settings lists the settings of cfg in the order of the struct fields.
+func settings(cfg *Config) []setting {
+	var all []setting
+	var walk func(prefix string, v reflect.Value)
+	walk = func(prefix string, v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			key := prefix + field.Tag.Get("yaml")
+			if field.Type.Kind() == reflect.Struct {
+				walk(key+".", v.Field(i))
+				continue
+			}
+			all = append(all, setting{
+				key:    key,
+				env:    field.Tag.Get("env"),
+				secret: field.Tag.Get("secret") == "true",
+				usage:  field.Tag.Get("usage"),
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk("", reflect.ValueOf(cfg).Elem())
+	return all
+}
+
+// Load reads the configuration: the defaults, then the file given with
+// -config or CONFIG_FILE, then the environment, then the flags in args.
+// Every setting gets a flag named after its key, e.g. -db.host, on flags,
+// which is parsed up to the first argument that is not a flag; the rest is
+// left in flags.Args().
+//
+// When some values cannot be read or the configuration is not valid, Load
+// returns Problems listing all of them, along with the configuration as far
+// as it could be read.
+func Load(flags *flag.FlagSet, args []string) (*Config, error) {
+	cfg := Default()
+	all := settings(cfg)
+
+	file := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML configuration `file` (CONFIG_FILE)")
+	for _, s := range all {
+		s.defineFlag(flags)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	flagValues := make(map[string]string)
+	flags.Visit(func(f *flag.Flag) {
+		flagValues[f.Name] = f.Value.String()
+	})
+
+	var problems Problems
+	if *file != "" {
+		problems = append(problems, loadFile(*file, all)...)
+	}
+	for _, s := range all {
+		if s.env == "" {
+			continue
+		}
+		value, ok := os.LookupEnv(s.env)
+		if !ok || (value == "" && s.value.Kind() != reflect.String) {
+			continue
+		}
+		if err := s.set(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", s.env, err))
+		}
+	}
+	for _, s := range all {
+		if value, ok := flagValues[s.key]; ok {
+			if err := s.set(value); err != nil {
+				problems = append(problems, fmt.Sprintf("-%s: %v", s.key, err))
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		problems = append(problems, err.(Problems)...)
+	}
+	if len(problems) > 0 {
+		return cfg, problems
+	}
+	return cfg, nil
+}
+
+// loadFile applies the settings in a YAML or TOML file, told apart by the
+// extension of path.
+func loadFile(path string, all []setting) Problems {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Problems{err.Error()}
+	}
+
+	values := make(map[string]interface{})
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return Problems{fmt.Sprintf("%s: unknown configuration format %q, use .yaml, .yml or .toml", path, ext)}
+	}
+	if err != nil {
+		return Problems{fmt.Sprintf("%s: %v", path, err)}
+	}
+
+	flat := make(map[string]interface{})
+	flatten("", values, flat)
+	var problems Problems
+	for _, s := range all {
+		value, ok := flat[s.key]
+		if !ok {
+			continue
+		}
+		delete(flat, s.key)
+		if err := s.set(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s: %v", path, s.key, err))
+		}
+	}
+	unknown := make([]string, 0, len(flat))
+	for key := range flat {
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		problems = append(problems, fmt.Sprintf("%s: unknown setting %q", path, key))
+	}
+	return problems
+}
+
+// flatten turns nested sections into dotted keys.
+func flatten(prefix string, values map[string]interface{}, flat map[string]interface{}) {
+	for key, value := range values {
+		if section, ok := value.(map[string]interface{}); ok {
+			flatten(prefix+key+".", section, flat)
+			continue
+		}
+		flat[prefix+key] = value
+	}
+}
+
+// set parses raw, a string from the environment or a flag or a value
+// decoded from a file, into the setting.
+func (s setting) set(raw interface{}) error {
+	if s.value.Type() == durationType {
+		switch raw := raw.(type) {
+		case string:
+			d, err := time.ParseDuration(raw)
+			if err != nil {
+				return fmt.Errorf("invalid duration %q, use a number with a unit such as 30s or 15m", raw)
+			}
+			if d < 0 {
+				return fmt.Errorf("duration %s must not be negative", raw)
+			}
+			s.value.SetInt(int64(d))
+			return nil
+		case int, int64:
+			if fmt.Sprint(raw) == "0" {
+				s.value.SetInt(0)
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid duration %v, use a number with a unit such as 30s or 15m", raw)
+	}
+
+	switch s.value.Kind() {
+	case reflect.String:
+		switch raw.(type) {
+		case map[string]interface{}, []interface{}:
+			return fmt.Errorf("invalid value %v, want a string", raw)
+		}
+		s.value.SetString(fmt.Sprint(raw))
+	case reflect.Bool:
+		if b, ok := raw.(bool); ok {
+			s.value.SetBool(b)
+			return nil
+		}
+		b, err := strconv.ParseBool(fmt.Sprint(raw))
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q, use true or false", fmt.Sprint(raw))
+		}
+		s.value.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(fmt.Sprint(raw), 10, 64)
+		if err != nil || s.value.OverflowInt(n) {
+			return fmt.Errorf("invalid integer %q", fmt.Sprint(raw))
+		}
+		s.value.SetInt(n)
+	case reflect.Slice:
+		var items []string
+		switch raw := raw.(type) {
+		case []interface{}:
+			for _, item := range raw {
+				items = append(items, fmt.Sprint(item))
+			}
+		default:
+			items = strings.Split(fmt.Sprint(raw), ",")
+		}
+		var list []string
+		for _, item := range items {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		s.value.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.value.Type())
+	}
+	return nil
+}
+
+// String formats the value of the setting as it is read.
+func (s setting) String() string {
+	if s.value.Type() == durationType {
+		return time.Duration(s.value.Int()).String()
+	}
+	if s.value.Kind() == reflect.Slice {
+		return strings.Join(s.value.Interface().([]string), ",")
+	}
+	return fmt.Sprint(s.value.Interface())
+}
+
+// defineFlag adds the flag of the setting to flags, with the default of
+// the setting unless it is a secret.
+func (s setting) defineFlag(flags *flag.FlagSet) {
+	usage := s.usage
+	if s.env != "" {
+		usage += " (" + s.env + ")"
+	}
+	switch {
+	case s.value.Type() == durationType:
+		flags.Duration(s.key, time.Duration(s.value.Int()), usage)
+	case s.value.Kind() == reflect.Bool:
+		flags.Bool(s.key, s.value.Bool(), usage)
+	case s.value.Kind() == reflect.Int:
+		flags.Int(s.key, int(s.value.Int()), usage)
+	case s.value.Kind() == reflect.Int64:
+		flags.Int64(s.key, s.value.Int(), usage)
+	case s.secret:
+		flags.String(s.key, "", usage)
+	default:
+		flags.String(s.key, s.String(), usage)
+	}
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"io"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted replaces the value of secrets in Print.
+const Redacted = "[redacted]"
+
+// Print writes the configuration as a YAML file that Load can read back,
+// with the environment variable of each setting as a comment. Secrets that
+// are set are replaced by Redacted.
+func (c *Config) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	sections := map[string]*yaml.Node{"": root}
+
+	for _, s := range settings(c) {
+		// Find or add the sections leading to the setting.
+		parent := root
+		parts := strings.Split(s.key, ".")
+		for i := range parts[:len(parts)-1] {
+			path := strings.Join(parts[:i+1], ".")
+			section, ok := sections[path]
+			if !ok {
+				section = &yaml.Node{Kind: yaml.MappingNode}
+				sections[path] = section
+				parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: parts[i]}, section)
+			}
+			parent = section
+		}
+
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: parts[len(parts)-1]}
+		value := s.node()
+		parent.Content = append(parent.Content, key, value)
+		if s.env != "" {
+			// Comments on keys are lost before flow sequences.
+			if value.Kind == yaml.SequenceNode {
+				value.LineComment = s.env
+			} else {
+				key.LineComment = s.env
+			}
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// node is the value of the setting in Print.
+func (s setting) node() *yaml.Node {
+	if s.secret {
+		value := ""
+		if s.String() != "" {
+			value = Redacted
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	}
+
+	switch {
+	case s.value.Type() == durationType:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s.String()}
+	case s.value.Kind() == reflect.Slice:
+		list := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for _, item := range s.value.Interface().([]string) {
+			list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item})
+		}
+		return list
+	case s.value.Kind() == reflect.Bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: s.String()}
+	case s.value.Kind() == reflect.String:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s.String()}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: s.String()}
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Validate returns Problems listing every setting that the server cannot
+// run with, or nil. Load validates the configuration it reads.
+func (c *Config) Validate() error {
+	envs := make(map[string]string)
+	for _, s := range settings(c) {
+		envs[s.key] = s.env
+	}
+	var problems Problems
+	problem := func(key, format string, args ...interface{}) {
+		if env := envs[key]; env != "" {
+			key += " (" + env + ")"
+		}
+		problems = append(problems, key+": "+fmt.Sprintf(format, args...))
+	}
+	positive := func(key string, d time.Duration) {
+		if d <= 0 {
+			problem(key, "must be longer than 0")
+		}
+	}
+	port := func(key string, port int) {
+		if port < 1 || port > 65535 {
+			problem(key, "%d is not a port, use 1 to 65535", port)
+		}
+	}
+
+	development := c.Env == EnvDevelopment
+	if c.Env != EnvDevelopment && c.Env != EnvProduction {
+		problem("env", "%q is neither %s nor %s", c.Env, EnvDevelopment, EnvProduction)
+	}
+
+	switch c.DB.Driver {
+	case DBDriverPostgres:
+		if c.DB.Host == "" {
+			problem("db.host", "is required")
+		}
+		port("db.port", c.DB.Port)
+		if c.DB.User == "" {
+			problem("db.user", "is required")
+		}
+		if c.DB.Password == "" && !development {
+			problem("db.password", "is required outside development")
+		}
+		if c.DB.Name == "" {
+			problem("db.name", "is required")
+		}
+		switch c.DB.SSLMode {
+		case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+		default:
+			problem("db.ssl_mode", "%q is not one of disable, allow, prefer, require, verify-ca or verify-full", c.DB.SSLMode)
+		}
+		if (c.DB.SSLCert == "") != (c.DB.SSLKey == "") {
+			problem("db.ssl_key", "db.ssl_cert and db.ssl_key go together")
+		}
+	case DBDriverSQLite:
+		if c.DB.Path == "" {
+			problem("db.path", "is required")
+		}
+		if len(c.DB.ReplicaDSNs) > 0 {
+			problem("db.replica_dsns", "replicas need PostgreSQL")
+		}
+		// An in-memory database is gone once its last connection closes.
+		if c.DB.Path == ":memory:" {
+			if c.DB.Pool.MaxIdleConns == 0 {
+				problem("db.pool.max_idle_conns", "must be at least 1 for an in-memory database")
+			}
+			if c.DB.Pool.ConnMaxLifetime != 0 {
+				problem("db.pool.conn_max_lifetime", "must be 0 for an in-memory database")
+			}
+			if c.DB.Pool.ConnMaxIdleTime != 0 {
+				problem("db.pool.conn_max_idle_time", "must be 0 for an in-memory database")
+			}
+		}
+	default:
+		problem("db.driver", "%q is neither %s nor %s", c.DB.Driver, DBDriverPostgres, DBDriverSQLite)
+	}
+	if c.DB.Pool.MaxOpenConns < 0 {
+		problem("db.pool.max_open_conns", "must not be negative")
+	}
+	if c.DB.Pool.MaxIdleConns < 0 {
+		problem("db.pool.max_idle_conns", "must not be negative")
+	}
+
+	port("http.port", c.HTTP.Port)
+	positive("http.read_header_timeout", c.HTTP.ReadHeaderTimeout)
+	positive("http.idle_timeout", c.HTTP.IdleTimeout)
+	positive("http.shutdown_timeout", c.HTTP.ShutdownTimeout)
+
+	if c.Auth.JWTSigningKeyFile == "" && !development && (c.Auth.JWTSecret == "" || c.Auth.JWTSecret == DefaultJWTSecret) {
+		problem("auth.jwt_secret", "must be set outside development, or use auth.jwt_signing_key_file")
+	}
+	positive("auth.access_token_ttl", c.Auth.AccessTokenTTL)
+	positive("auth.refresh_token_ttl", c.Auth.RefreshTokenTTL)
+
+	if c.Storage.UploadDir == "" {
+		problem("storage.upload_dir", "is required")
+	}
+	sizes := []struct {
+		key  string
+		size int64
+	}{
+		{"storage.max_upload_size", c.Storage.MaxUploadSize},
+		{"storage.max_image_size", c.Storage.MaxImageSize},
+		{"storage.max_subtitle_size", c.Storage.MaxSubtitleSize},
+	}
+	for _, size := range sizes {
+		if size.size <= 0 {
+			problem(size.key, "must be more than 0 bytes")
+		}
+	}
+
+	positive("media.url_ttl", c.Media.URLTTL)
+	positive("media.hls_segment_duration", c.Media.HLSSegmentDuration)
+
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateInMemorySQLitePool(t *testing.T) {
+	tests := []struct {
+		name  string
+		pool  func(*DBPoolConfig)
+		wants []string
+	}{
+		{"default pool", func(*DBPoolConfig) {}, nil},
+		{"no idle connections", func(p *DBPoolConfig) { p.MaxIdleConns = 0 }, []string{"db.pool.max_idle_conns"}},
+		{"connection lifetime", func(p *DBPoolConfig) { p.ConnMaxLifetime = time.Hour }, []string{"db.pool.conn_max_lifetime"}},
+		{"idle time", func(p *DBPoolConfig) { p.ConnMaxIdleTime = time.Minute }, []string{"db.pool.conn_max_idle_time"}},
+	}
+	for _, test := range tests {
+		cfg := Default()
+		cfg.Env = EnvDevelopment
+		cfg.DB.Driver = DBDriverSQLite
+		cfg.DB.Path = ":memory:"
+		test.pool(&cfg.DB.Pool)
+
+		var problems Problems
+		if err := cfg.Validate(); err != nil && !errors.As(err, &problems) {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if len(problems) != len(test.wants) {
+			t.Errorf("%s: got problems %q, want %q", test.name, problems, test.wants)
+			continue
+		}
+		for i, want := range test.wants {
+			if !strings.HasPrefix(problems[i], want) {
+				t.Errorf("%s: got problem %q, want one about %s", test.name, problems[i], want)
+			}
+		}
+
+		// A database file keeps its data without open connections.
+		cfg.DB.Path = "movies.db"
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("%s with a file: %v", test.name, err)
+		}
+	}
+}
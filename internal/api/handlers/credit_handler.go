@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/services"
+)
+
+type CreditHandler struct {
+	creditService *services.CreditService
+}
+
+func NewCreditHandler(creditService *services.CreditService) *CreditHandler {
+	return &CreditHandler{
+		creditService: creditService,
+	}
+}
+
+// @Summary Get a movie's credits
+// @Description Get the cast and crew of a movie ordered by role and billing order
+// @Tags credits
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Success 200 {array} models.Credit
+// @Failure 404 {object} object
+// @Router /movies/{id}/credits [get]
+func (h *CreditHandler) GetMovieCredits(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	credits, err := h.creditService.GetMovieCredits(uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrMovieNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve credits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, credits)
+}
+
+// @Summary Add a credit to a movie
+// @Description Credit a person on a movie as director, actor or writer
+// @Tags credits
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param credit body models.CreditRequest true "Credit information"
+// @Success 201 {object} models.Credit
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /movies/{id}/credits [post]
+func (h *CreditHandler) AddCredit(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req models.CreditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	credit, err := h.creditService.AddCredit(uint(id), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMovieNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		case errors.Is(err, services.ErrPersonNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add credit"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, credit)
+}
+
+// @Summary Remove a credit from a movie
+// @Description Delete one credit of a movie
+// @Tags credits
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param creditId path int true "Credit ID"
+// @Success 204
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /movies/{id}/credits/{creditId} [delete]
+func (h *CreditHandler) DeleteCredit(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	creditID, err := strconv.ParseUint(c.Param("creditId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	if err := h.creditService.DeleteCredit(uint(movieID), uint(creditID)); err != nil {
+		if errors.Is(err, services.ErrCreditNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Credit not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete credit"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/services"
+)
+
+type GenreHandler struct {
+	genreService *services.GenreService
+}
+
+func NewGenreHandler(genreService *services.GenreService) *GenreHandler {
+	return &GenreHandler{
+		genreService: genreService,
+	}
+}
+
+// @Summary Get all genres
+// @Description Get the list of genres ordered by name
+// @Tags genres
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.Genre
+// @Router /genres [get]
+func (h *GenreHandler) GetAllGenres(c *gin.Context) {
+	genres, err := h.genreService.GetAllGenres()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve genres"})
+		return
+	}
+
+	c.JSON(http.StatusOK, genres)
+}
+
+// @Summary Get a genre by ID
+// @Description Get details of a specific genre
+// @Tags genres
+// @Accept json
+// @Produce json
+// @Param id path int true "Genre ID"
+// @Success 200 {object} models.Genre
+// @Failure 404 {object} object
+// @Router /genres/{id} [get]
+func (h *GenreHandler) GetGenreByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	genre, err := h.genreService.GetGenreByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve genre"})
+		return
+	}
+
+	if genre == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Genre not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, genre)
+}
+
+// @Summary Create a new genre
+// @Description Add a new genre
+// @Tags genres
+// @Accept json
+// @Produce json
+// @Param genre body models.GenreRequest true "Genre information"
+// @Success 201 {object} models.Genre
+// @Failure 400 {object} object
+// @Failure 409 {object} object
+// @Router /genres [post]
+func (h *GenreHandler) CreateGenre(c *gin.Context) {
+	var req models.GenreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	genre, err := h.genreService.CreateGenre(&req)
+	if err != nil {
+		if errors.Is(err, services.ErrGenreExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create genre"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, genre)
+}
+
+// @Summary Update a genre
+// @Description Rename an existing genre
+// @Tags genres
+// @Accept json
+// @Produce json
+// @Param id path int true "Genre ID"
+// @Param genre body models.GenreRequest true "Genre information"
+// @Success 200 {object} models.Genre
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Failure 409 {object} object
+// @Router /genres/{id} [put]
+func (h *GenreHandler) UpdateGenre(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req models.GenreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	genre, err := h.genreService.UpdateGenre(uint(id), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrGenreNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Genre not found"})
+		case errors.Is(err, services.ErrGenreExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update genre"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, genre)
+}
+
+// @Summary Delete a genre
+// @Description Delete a genre and remove it from every movie
+// @Tags genres
+// @Accept json
+// @Produce json
+// @Param id path int true "Genre ID"
+// @Success 204
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /genres/{id} [delete]
+func (h *GenreHandler) DeleteGenre(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	if err := h.genreService.DeleteGenre(uint(id)); err != nil {
+		if errors.Is(err, services.ErrGenreNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Genre not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete genre"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
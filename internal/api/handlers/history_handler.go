@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/services"
+)
+
+type HistoryHandler struct {
+	historyService *services.HistoryService
+}
+
+func NewHistoryHandler(historyService *services.HistoryService) *HistoryHandler {
+	return &HistoryHandler{
+		historyService: historyService,
+	}
+}
+
+// @Summary Get your watched history
+// @Description Get a paginated list of the movies the authenticated user watched, most recent first
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number (starts at 1)" minimum(1) default(1)
+// @Param limit query int false "Entries per page" minimum(1) maximum(100) default(20)
+// @Success 200 {object} models.WatchedListResponse
+// @Failure 400 {object} object
+// @Router /me/history [get]
+func (h *HistoryHandler) GetHistory(c *gin.Context) {
+	var query models.WatchedListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, total, err := h.historyService.GetHistory(c.GetUint("userID"), &query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve history"})
+		return
+	}
+
+	response := models.WatchedListResponse{
+		Data:  entries,
+		Total: total,
+		Page:  query.Page,
+		Limit: query.Limit,
+	}
+	if int64(query.Page*query.Limit) < total {
+		response.Next = pageLink(c, query.Page+1, query.Limit)
+	}
+	if query.Page > 1 {
+		response.Prev = pageLink(c, query.Page-1, query.Limit)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Log a watched movie
+// @Description Record that the authenticated user watched a movie, optionally on a given date and as a rewatch
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param entry body models.WatchedEntryRequest true "Watched entry"
+// @Success 201 {object} models.WatchedEntry
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /me/history [post]
+func (h *HistoryHandler) AddWatchedEntry(c *gin.Context) {
+	var req models.WatchedEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.historyService.AddWatchedEntry(c.GetUint("userID"), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrMovieNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log watched movie"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// @Summary Delete a watched entry
+// @Description Remove an entry from the authenticated user's watched history
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param entryId path int true "Watched entry ID"
+// @Success 204
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /me/history/{entryId} [delete]
+func (h *HistoryHandler) DeleteWatchedEntry(c *gin.Context) {
+	entryID, err := strconv.ParseUint(c.Param("entryId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	if err := h.historyService.DeleteWatchedEntry(c.GetUint("userID"), uint(entryID)); err != nil {
+		if errors.Is(err, services.ErrWatchedEntryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Watched entry not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete watched entry"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mehmonov/movies-crud/internal/services"
+	"github.com/mehmonov/movies-crud/pkg/imaging"
+)
+
+type ImageHandler struct {
+	imageService *services.ImageService
+}
+
+func NewImageHandler(imageService *services.ImageService) *ImageHandler {
+	return &ImageHandler{
+		imageService: imageService,
+	}
+}
+
+// @Summary Upload a poster or backdrop
+// @Description Upload a JPEG, PNG or WebP image as the "file" field of a multipart form. It replaces the movie's current image of that kind. Thumbnails are generated and EXIF metadata is removed.
+// @Tags images
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param kind path string true "Image kind" Enums(poster, backdrop)
+// @Param file formData file true "Image file"
+// @Success 200 {object} models.MovieImage
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Failure 413 {object} object
+// @Failure 415 {object} object
+// @Router /movies/{id}/images/{kind} [put]
+func (h *ImageHandler) SetImage(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.imageService.MaxUploadSize()+1<<20)
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expected a multipart/form-data request"})
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing file field"})
+			return
+		}
+		if err != nil {
+			h.respondImageError(c, err)
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		image, err := h.imageService.SetImage(c.Request.Context(), uint(movieID), c.Param("kind"), part)
+		part.Close()
+		if err != nil {
+			h.respondImageError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, image)
+		return
+	}
+}
+
+// @Summary Get a movie image
+// @Description Get the original or a thumbnail of a poster or backdrop. The available sizes are listed in the urls of the image.
+// @Tags images
+// @Produce image/jpeg,image/png
+// @Param id path int true "Movie ID"
+// @Param imageId path int true "Image ID"
+// @Param size path string true "original or w<width>, e.g. w185"
+// @Success 200 {file} file
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /movies/{id}/images/{imageId}/{size} [get]
+func (h *ImageHandler) GetImage(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	imageID, err := strconv.ParseUint(c.Param("imageId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	image, blob, err := h.imageService.OpenImage(c.Request.Context(), uint(movieID), uint(imageID), c.Param("size"))
+	if err != nil {
+		if errors.Is(err, services.ErrImageNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open image"})
+		return
+	}
+	defer blob.Close()
+
+	// A new upload gets a new image ID, so a URL always serves the same file.
+	c.Header("Content-Type", imaging.ContentType(image.Format))
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeContent(c.Writer, c.Request, "", blob.Info().ModTime, blob)
+}
+
+// @Summary Delete a poster or backdrop
+// @Description Remove the movie's image of the given kind
+// @Tags images
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param kind path string true "Image kind" Enums(poster, backdrop)
+// @Success 204
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /movies/{id}/images/{kind} [delete]
+func (h *ImageHandler) DeleteImage(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	if err := h.imageService.DeleteImage(c.Request.Context(), uint(movieID), c.Param("kind")); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidImageKind):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrImageNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete image"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ImageHandler) respondImageError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, services.ErrMovieNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+	case errors.Is(err, services.ErrInvalidImageKind), errors.Is(err, imaging.ErrInvalidImage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrImageTooLarge), errors.As(err, &maxBytesErr):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrImageTooLarge.Error()})
+	case errors.Is(err, imaging.ErrTooManyPixels):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, imaging.ErrUnsupportedFormat):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+	}
+}
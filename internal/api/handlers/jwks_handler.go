@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mehmonov/movies-crud/pkg/auth"
+)
+
+type JWKSHandler struct {
+	jwtService *auth.JWTService
+}
+
+func NewJWKSHandler(jwtService *auth.JWTService) *JWKSHandler {
+	return &JWKSHandler{
+		jwtService: jwtService,
+	}
+}
+
+// GetJWKS serves the public keys that verify access tokens as a JSON Web Key
+// Set. It is mounted at /.well-known/jwks.json, outside the API base path,
+// and at /auth/jwks.json, where the API documentation lists it.
+//
+// @Summary Get the token signing keys
+// @Description Get the public keys that verify access tokens, as a JSON Web Key Set (RFC 7517). Tokens name their key in the kid header. The same set is served at /.well-known/jwks.json, outside the API base path, and may be cached for 5 minutes.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} auth.JWKSet
+// @Header 200 {string} Cache-Control "public, max-age=300"
+// @Failure 500 {object} object
+// @Router /auth/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	set, err := h.jwtService.JWKS()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build key set"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
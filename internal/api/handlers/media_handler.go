@@ -0,0 +1,486 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/services"
+	"github.com/mehmonov/movies-crud/pkg/auth"
+)
+
+// hlsContentTypes maps the extensions of HLS files to their content type.
+var hlsContentTypes = map[string]string{
+	".m3u8": "application/vnd.apple.mpegurl",
+	".mp4":  "video/mp4",
+	".m4s":  "video/iso.segment",
+}
+
+type MediaHandler struct {
+	mediaService *services.MediaService
+	hlsService   *services.HLSService
+	urlSigner    *auth.URLSigner
+	// signPlaylists makes HLS playlists link to signed URLs, for when
+	// streaming requires them.
+	signPlaylists bool
+}
+
+func NewMediaHandler(mediaService *services.MediaService, hlsService *services.HLSService, urlSigner *auth.URLSigner, signPlaylists bool) *MediaHandler {
+	return &MediaHandler{
+		mediaService:  mediaService,
+		hlsService:    hlsService,
+		urlSigner:     urlSigner,
+		signPlaylists: signPlaylists,
+	}
+}
+
+// @Summary Get movie media
+// @Description Get the video files uploaded for a movie
+// @Tags media
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Success 200 {array} models.MediaFile
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /movies/{id}/media [get]
+func (h *MediaHandler) GetMovieMedia(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	media, err := h.mediaService.GetMovieMedia(uint(movieID))
+	if err != nil {
+		if errors.Is(err, services.ErrMovieNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve media"})
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+// @Summary Upload a movie video
+// @Description Upload an MP4 or WebM video for a movie as the "file" field of a multipart form. The file is streamed to storage, so it is never held in memory. Uploading the same file again returns the existing record with status 200.
+// @Tags media
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param file formData file true "Video file"
+// @Success 200 {object} models.MediaFile
+// @Success 201 {object} models.MediaFile
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Failure 413 {object} object
+// @Failure 415 {object} object
+// @Router /movies/{id}/media [post]
+func (h *MediaHandler) UploadMedia(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	// Leave some room for the multipart headers around the file.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.mediaService.MaxUploadSize()+1<<20)
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expected a multipart/form-data request"})
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing file field"})
+			return
+		}
+		if err != nil {
+			h.respondUploadError(c, err)
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		media, created, err := h.mediaService.UploadMedia(c.Request.Context(), uint(movieID), part.FileName(), part)
+		part.Close()
+		if err != nil {
+			h.respondUploadError(c, err)
+			return
+		}
+
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		c.JSON(status, media)
+		return
+	}
+}
+
+// @Summary Start a resumable upload
+// @Description Start a resumable upload of a movie video. Send the file with PATCH requests to the returned session, each with an Upload-Offset header.
+// @Tags media
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param upload body models.UploadSessionRequest true "File to upload"
+// @Success 201 {object} models.UploadSession
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Failure 413 {object} object
+// @Router /movies/{id}/media/uploads [post]
+func (h *MediaHandler) CreateUploadSession(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req models.UploadSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.mediaService.CreateUploadSession(uint(movieID), &req)
+	if err != nil {
+		h.respondUploadError(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("%s/%s", c.Request.URL.Path, session.ID))
+	c.JSON(http.StatusCreated, session)
+}
+
+// @Summary Get a resumable upload
+// @Description Get the state of a resumable upload. The Upload-Offset header tells where the next chunk starts.
+// @Tags media
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param uploadId path string true "Upload session ID"
+// @Success 200 {object} models.UploadSession
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /movies/{id}/media/uploads/{uploadId} [get]
+func (h *MediaHandler) GetUploadSession(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	session, err := h.mediaService.GetUploadSession(uint(movieID), c.Param("uploadId"))
+	if err != nil {
+		h.respondUploadError(c, err)
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	c.JSON(http.StatusOK, session)
+}
+
+// @Summary Upload a chunk
+// @Description Append the request body to a resumable upload. Upload-Offset must equal the bytes received so far. When the last chunk arrives the video is stored and returned as media.
+// @Tags media
+// @Accept application/offset+octet-stream
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param uploadId path string true "Upload session ID"
+// @Param Upload-Offset header int true "Offset of this chunk"
+// @Success 200 {object} models.UploadChunkResponse
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Failure 409 {object} object
+// @Failure 413 {object} object
+// @Failure 415 {object} object
+// @Router /movies/{id}/media/uploads/{uploadId} [patch]
+func (h *MediaHandler) UploadChunk(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing or invalid Upload-Offset header"})
+		return
+	}
+
+	session, media, err := h.mediaService.AppendUploadChunk(c.Request.Context(), uint(movieID), c.Param("uploadId"), offset, c.Request.Body)
+	if session != nil {
+		c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	}
+	if err != nil {
+		h.respondUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UploadChunkResponse{Session: session, Media: media})
+}
+
+// @Summary Stream a movie video
+// @Description Stream a video file of a movie. Supports byte ranges (206 Partial Content), conditional requests with ETag and Last-Modified, and If-Range. When signed URLs are required, pass either a bearer token or the expires and signature parameters of a URL from the stream-url endpoint.
+// @Tags media
+// @Produce video/mp4,video/webm
+// @Param id path int true "Movie ID"
+// @Param mediaId path int true "Media file ID"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
+// @Param expires query int false "Expiry of a signed URL (Unix seconds)"
+// @Param signature query string false "Signature of a signed URL"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 400 {object} object
+// @Failure 403 {object} object
+// @Failure 404 {object} object
+// @Failure 416 {object} object
+// @Router /movies/{id}/media/{mediaId}/stream [get]
+func (h *MediaHandler) StreamMedia(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	mediaID, err := strconv.ParseUint(c.Param("mediaId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	media, blob, err := h.mediaService.OpenMedia(c.Request.Context(), uint(movieID), uint(mediaID))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMovieNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		case errors.Is(err, services.ErrMediaNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open media"})
+		}
+		return
+	}
+	defer blob.Close()
+
+	// The content never changes for a stored file, as it is named after its
+	// hash, so the hash makes a strong validator. ServeContent uses it for
+	// If-None-Match and If-Range and handles the Range header.
+	c.Header("Content-Type", media.ContentType)
+	c.Header("ETag", `"`+media.SHA256+`"`)
+	http.ServeContent(c.Writer, c.Request, media.Filename, blob.Info().ModTime, blob)
+}
+
+// @Summary Get a signed stream URL
+// @Description Get a URL that streams a video without an Authorization header, for use in a <video> element. It is valid for MEDIA_URL_TTL (4 hours by default), which should outlast the video, as players keep requesting ranges of it while they play.
+// @Tags media
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param mediaId path int true "Media file ID"
+// @Success 200 {object} models.StreamURLResponse
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /movies/{id}/media/{mediaId}/stream-url [get]
+func (h *MediaHandler) GetStreamURL(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	mediaID, err := strconv.ParseUint(c.Param("mediaId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	media, err := h.mediaService.GetMedia(uint(movieID), uint(mediaID))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMovieNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		case errors.Is(err, services.ErrMediaNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve media"})
+		}
+		return
+	}
+
+	base := strings.TrimSuffix(c.Request.URL.Path, "/stream-url")
+	query, expiresAt := h.urlSigner.Sign(base + "/stream")
+	response := models.StreamURLResponse{
+		URL:       fmt.Sprintf("%s/stream?%s", base, query.Encode()),
+		ExpiresAt: expiresAt,
+	}
+	if media.HLSStatus == models.HLSStatusReady {
+		playlist := base + "/hls/" + services.HLSPlaylistName
+		query, _ := h.urlSigner.Sign(playlist)
+		response.HLSURL = fmt.Sprintf("%s?%s", playlist, query.Encode())
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Get an HLS file
+// @Description Get the HLS playlist (index.m3u8), init segment (init.mp4) or a media segment of a video. MP4 uploads are packaged in the background; see hls_status on the media file. Access is gated like the stream endpoint.
+// @Tags media
+// @Produce application/vnd.apple.mpegurl,video/mp4,video/iso.segment
+// @Param id path int true "Movie ID"
+// @Param mediaId path int true "Media file ID"
+// @Param file path string true "File name, e.g. index.m3u8"
+// @Param expires query int false "Expiry of a signed URL (Unix seconds)"
+// @Param signature query string false "Signature of a signed URL"
+// @Success 200 {file} file
+// @Failure 400 {object} object
+// @Failure 403 {object} object
+// @Failure 404 {object} object
+// @Failure 409 {object} object
+// @Router /movies/{id}/media/{mediaId}/hls/{file} [get]
+func (h *MediaHandler) GetHLSFile(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	mediaID, err := strconv.ParseUint(c.Param("mediaId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	name := c.Param("file")
+	blob, err := h.hlsService.OpenFile(c.Request.Context(), uint(movieID), uint(mediaID), name)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMovieNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		case errors.Is(err, services.ErrMediaNotFound), errors.Is(err, services.ErrHLSFileNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrHLSNotReady):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open HLS file"})
+		}
+		return
+	}
+	defer blob.Close()
+
+	c.Header("Content-Type", hlsContentTypes[path.Ext(name)])
+	if name == services.HLSPlaylistName && h.signPlaylists {
+		playlist, err := io.ReadAll(blob)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read playlist"})
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.String(http.StatusOK, h.signPlaylist(path.Dir(c.Request.URL.Path), string(playlist)))
+		return
+	}
+
+	// Packaged files never change, so they can be cached for good.
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeContent(c.Writer, c.Request, name, blob.Info().ModTime, blob)
+}
+
+// signPlaylist rewrites the segment and init segment URIs of an HLS
+// playlist served from dir into signed URLs. Players read a VOD playlist
+// once, so the URLs stay valid for the length of the video on top of the
+// TTL, and the last segment can still be fetched when playback gets there.
+func (h *MediaHandler) signPlaylist(dir, playlist string) string {
+	lines := strings.Split(playlist, "\n")
+	var duration time.Duration
+	for _, line := range lines {
+		if value, ok := strings.CutPrefix(line, "#EXTINF:"); ok {
+			value, _, _ = strings.Cut(value, ",")
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				duration += time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	sign := func(name string) string {
+		query, _ := h.urlSigner.SignFor(dir+"/"+name, duration)
+		return name + "?" + query.Encode()
+	}
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-MAP:URI="):
+			name := strings.Trim(strings.TrimPrefix(line, "#EXT-X-MAP:URI="), `"`)
+			lines[i] = fmt.Sprintf(`#EXT-X-MAP:URI="%s"`, sign(name))
+		case line != "" && !strings.HasPrefix(line, "#"):
+			lines[i] = sign(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// @Summary Delete a movie video
+// @Description Delete a video file of a movie from storage
+// @Tags media
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param mediaId path int true "Media file ID"
+// @Success 204
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /movies/{id}/media/{mediaId} [delete]
+func (h *MediaHandler) DeleteMedia(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	mediaID, err := strconv.ParseUint(c.Param("mediaId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	if err := h.mediaService.DeleteMedia(c.Request.Context(), uint(movieID), uint(mediaID)); err != nil {
+		if errors.Is(err, services.ErrMediaNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete media"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *MediaHandler) respondUploadError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, services.ErrMovieNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+	case errors.Is(err, services.ErrUploadSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrUploadOffsetMismatch):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrUploadChunkTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMediaTooLarge), errors.As(err, &maxBytesErr):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrMediaTooLarge.Error()})
+	case errors.Is(err, services.ErrUnsupportedMediaType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload media"})
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mehmonov/movies-crud/pkg/auth"
+)
+
+func TestSignPlaylistCoversPlayback(t *testing.T) {
+	signer := auth.NewURLSigner([]byte("secret"), 5*time.Minute)
+	h := &MediaHandler{urlSigner: signer, signPlaylists: true}
+	playlist := "#EXTM3U\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXT-X-MAP:URI=\"init.mp4\"\n" +
+		"#EXTINF:3600.000,\nseg_00001.m4s\n#EXTINF:1800.500,\nseg_00002.m4s\n#EXT-X-ENDLIST\n"
+	const dir = "/api/v1/movies/1/media/2/hls"
+
+	before := time.Now()
+	signed := h.signPlaylist(dir, playlist)
+
+	var uris []string
+	for _, line := range strings.Split(signed, "\n") {
+		if uri, ok := strings.CutPrefix(line, `#EXT-X-MAP:URI="`); ok {
+			uris = append(uris, strings.TrimSuffix(uri, `"`))
+		} else if line != "" && !strings.HasPrefix(line, "#") {
+			uris = append(uris, line)
+		}
+	}
+	if len(uris) != 3 {
+		t.Fatalf("found %d signed URIs in\n%s", len(uris), signed)
+	}
+	for _, uri := range uris {
+		name, rawQuery, _ := strings.Cut(uri, "?")
+		query, err := url.ParseQuery(rawQuery)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := signer.Verify(dir+"/"+name, query); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		expires, _ := strconv.ParseInt(query.Get("expires"), 10, 64)
+		// 1.5 hours of video plus the 5 minute TTL.
+		if want := before.Add(95 * time.Minute).Unix(); expires < want-1 {
+			t.Errorf("%s expires at %d, want at least %d", name, expires, want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+// movieETag is the entity tag of movie as GET /movies/{id} returns it. It
+// changes with the version, when the movie is edited, and with everything
+// else in the body, such as the rating or the media, which change without
+// a new version.
+func movieETag(movie *models.Movie) string {
+	body, _ := json.Marshal(movie)
+	sum := sha256.Sum256(body)
+	return `"` + strconv.Itoa(movie.Version) + "-" + base64.RawURLEncoding.EncodeToString(sum[:]) + `"`
+}
+
+// etagMatches reports whether header, the list of entity tags of an
+// If-Match or If-None-Match header, is "*" or names etag. If-Match uses the
+// strong comparison, in which weak tags (W/"...") never match, and
+// If-None-Match the weak one.
+func etagMatches(header, etag string, weak bool) bool {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = tag[2:]
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion returns the movie version that a write must apply to,
+// checked against the If-Match header, or 0 when the write may apply to any
+// version: without the header (unless it is required) or with "*". With a
+// list of ETags, the write goes ahead if the movie still has one of them.
+// If it returns false, the request has already been answered.
+func (h *MovieHandler) ifMatchVersion(c *gin.Context, id uint) (int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		if h.requireIfMatch {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header with the movie's ETag is required"})
+			return 0, false
+		}
+		return 0, true
+	}
+	if header == "*" {
+		return 0, true
+	}
+
+	movie, err := h.movieService.GetMovieByID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve movie"})
+		return 0, false
+	}
+	if movie == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		return 0, false
+	}
+	if !etagMatches(header, movieETag(movie), false) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Movie has been changed since it was read"})
+		return 0, false
+	}
+	// The write is made conditional on the version read here, so that an
+	// edit in between is still caught.
+	return movie.Version, true
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+func TestMovieETagChangesWithBody(t *testing.T) {
+	movie := &models.Movie{ID: 1, Title: "Heat", Version: 3}
+	before := movieETag(movie)
+	movie.Rating = 9
+	if after := movieETag(movie); after == before {
+		t.Errorf("ETag %s did not change with the rating", after)
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	const etag = `"3-abc"`
+	tests := []struct {
+		header string
+		weak   bool
+		want   bool
+	}{
+		{``, false, false},
+		{`*`, false, true},
+		{`"3-abc"`, false, true},
+		{`"2-xyz", "3-abc"`, false, true},
+		{`"2-xyz","4-def"`, false, false},
+		{`W/"3-abc"`, false, false},
+		{`W/"3-abc"`, true, true},
+		{`"3"`, true, false},
+	}
+	for _, test := range tests {
+		if got := etagMatches(test.header, etag, test.weak); got != test.want {
+			t.Errorf("etagMatches(%q, weak %v) = %v, want %v", test.header, test.weak, got, test.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+    "errors"
     "net/http"
     "strconv"
     
@@ -11,39 +12,128 @@ import (
 )
 
 type MovieHandler struct {
-    movieService *services.MovieService
+    movieService  *services.MovieService
+    movieSearcher services.MovieSearcher
+    // requireIfMatch makes updates and deletes answer 428 without an
+    // If-Match header.
+    requireIfMatch bool
 }
 
-func NewMovieHandler(movieService *services.MovieService) *MovieHandler {
+func NewMovieHandler(movieService *services.MovieService, movieSearcher services.MovieSearcher, requireIfMatch bool) *MovieHandler {
     return &MovieHandler{
-        movieService: movieService,
+        movieService:   movieService,
+        movieSearcher:  movieSearcher,
+        requireIfMatch: requireIfMatch,
     }
 }
 
 // @Summary Get all movies
-// @Description Get a list of all movies
+// @Description Get a paginated list of movies, optionally filtered and sorted
 // @Tags movies
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.Movie
+// @Param page query int false "Page number (starts at 1)" minimum(1) default(1)
+// @Param limit query int false "Movies per page" minimum(1) maximum(100) default(20)
+// @Param title query string false "Case-insensitive substring of the title"
+// @Param director query string false "Case-insensitive substring of the director"
+// @Param year_from query int false "Earliest release year (inclusive)"
+// @Param year_to query int false "Latest release year (inclusive)"
+// @Param genre query []int false "Only movies in any of these genre IDs" collectionFormat(multi)
+// @Param sort query string false "Comma separated sort fields (id, title, director, year, average_rating, vote_count, created_at, updated_at); prefix with - for descending, e.g. -year,title"
+// @Success 200 {object} models.MovieListResponse
+// @Failure 400 {object} object
 // @Router /movies [get]
 func (h *MovieHandler) GetAllMovies(c *gin.Context) {
-    movies, err := h.movieService.GetAllMovies()
+    var query models.MovieListQuery
+    if err := c.ShouldBindQuery(&query); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    
+    movies, total, err := h.movieService.GetAllMovies(&query)
     if err != nil {
+        if errors.Is(err, services.ErrInvalidSort) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve movies"})
         return
     }
     
-    c.JSON(http.StatusOK, movies)
+    response := models.MovieListResponse{
+        Data:  movies,
+        Total: total,
+        Page:  query.Page,
+        Limit: query.Limit,
+    }
+    if int64(query.Page*query.Limit) < total {
+        response.Next = pageLink(c, query.Page+1, query.Limit)
+    }
+    if query.Page > 1 {
+        response.Prev = pageLink(c, query.Page-1, query.Limit)
+    }
+    
+    c.JSON(http.StatusOK, response)
+}
+
+// pageLink returns the current request URL pointing at the given page,
+// keeping every other query parameter intact.
+func pageLink(c *gin.Context, page, limit int) string {
+    u := *c.Request.URL
+    values := u.Query()
+    values.Set("page", strconv.Itoa(page))
+    values.Set("limit", strconv.Itoa(limit))
+    u.RawQuery = values.Encode()
+    return u.RequestURI()
+}
+
+// @Summary Search movies
+// @Description Full-text search over title, director and plot. Words match by prefix, and titles or directors with small typos are still found. The highlight fields are HTML, escaped, with the matched words wrapped in <mark> tags.
+// @Tags movies
+// @Accept json
+// @Produce json
+// @Param q query string true "Search text"
+// @Param page query int false "Page number (starts at 1)" minimum(1) default(1)
+// @Param limit query int false "Results per page" minimum(1) maximum(100) default(20)
+// @Success 200 {object} models.MovieSearchResponse
+// @Failure 400 {object} object
+// @Router /movies/search [get]
+func (h *MovieHandler) SearchMovies(c *gin.Context) {
+    var query models.MovieSearchQuery
+    if err := c.ShouldBindQuery(&query); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if query.Page < 1 {
+        query.Page = 1
+    }
+    if query.Limit < 1 {
+        query.Limit = services.DefaultMoviePageSize
+    }
+    
+    results, err := h.movieSearcher.Search(query.Q, query.Page, query.Limit)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search movies"})
+        return
+    }
+    
+    c.JSON(http.StatusOK, models.MovieSearchResponse{
+        Data:  results,
+        Page:  query.Page,
+        Limit: query.Limit,
+    })
 }
 
 // @Summary Get a movie by ID
-// @Description Get details of a specific movie
+// @Description Get details of a specific movie. The ETag header identifies the movie as returned, including its rating and media; send it back in If-Match to update or delete the movie only if it has not changed since, or in If-None-Match to get 304 while it is unchanged. Both headers take a list of ETags.
 // @Tags movies
 // @Accept json
 // @Produce json
 // @Param id path int true "Movie ID"
+// @Param If-None-Match header string false "ETags of the movie the client already has"
 // @Success 200 {object} models.Movie
+// @Header 200 {string} ETag "Entity tag of the movie"
+// @Success 304
 // @Failure 404 {object} object
 // @Router /movies/{id} [get]
 func (h *MovieHandler) GetMovieByID(c *gin.Context) {
@@ -65,6 +155,12 @@ func (h *MovieHandler) GetMovieByID(c *gin.Context) {
         return
     }
     
+    etag := movieETag(movie)
+    c.Header("ETag", etag)
+    if etagMatches(c.GetHeader("If-None-Match"), etag, true) {
+        c.Status(http.StatusNotModified)
+        return
+    }
     c.JSON(http.StatusOK, movie)
 }
 
@@ -84,8 +180,12 @@ func (h *MovieHandler) CreateMovie(c *gin.Context) {
         return
     }
     
-    movie, err := h.movieService.CreateMovie(&req)
+    movie, err := h.movieService.CreateMovie(&req, c.GetUint("userID"))
     if err != nil {
+        if errors.Is(err, services.ErrGenreNotFound) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create movie"})
         return
     }
@@ -94,15 +194,19 @@ func (h *MovieHandler) CreateMovie(c *gin.Context) {
 }
 
 // @Summary Update a movie
-// @Description Update an existing movie's details
+// @Description Update an existing movie's details. With an If-Match header the update is only made if the movie is still at that ETag; the header is required when the server runs with REQUIRE_IF_MATCH.
 // @Tags movies
 // @Accept json
 // @Produce json
 // @Param id path int true "Movie ID"
+// @Param If-Match header string false "ETags of the movie from GET /movies/{id}"
 // @Param movie body models.UpdateMovieRequest true "Movie information"
 // @Success 200 {object} models.Movie
+// @Header 200 {string} ETag "Entity tag of the updated movie"
 // @Failure 400 {object} object
 // @Failure 404 {object} object
+// @Failure 412 {object} object
+// @Failure 428 {object} object
 // @Router /movies/{id} [put]
 func (h *MovieHandler) UpdateMovie(c *gin.Context) {
     idParam := c.Param("id")
@@ -112,29 +216,52 @@ func (h *MovieHandler) UpdateMovie(c *gin.Context) {
         return
     }
     
+    version, ok := h.ifMatchVersion(c, uint(id))
+    if !ok {
+        return
+    }
+    
     var req models.UpdateMovieRequest
     if err := c.ShouldBindJSON(&req); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
     
-    if err := h.movieService.UpdateMovie(uint(id), &req); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update movie"})
+    if err := h.movieService.UpdateMovie(uint(id), version, &req, c.GetUint("userID")); err != nil {
+        switch {
+        case errors.Is(err, services.ErrGenreNotFound):
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        case errors.Is(err, services.ErrMovieNotFound):
+            c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+        case errors.Is(err, services.ErrMovieVersionMismatch):
+            c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Movie has been changed since it was read"})
+        default:
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update movie"})
+        }
         return
     }
     
-    movie, _ := h.movieService.GetMovieByID(uint(id))
+    movie, err := h.movieService.GetMovieByID(uint(id))
+    if err != nil || movie == nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve movie"})
+        return
+    }
+    c.Header("ETag", movieETag(movie))
     c.JSON(http.StatusOK, movie)
 }
 
 // @Summary Delete a movie
-// @Description Delete a movie from the database
+// @Description Move a movie to the trash, from which admins can restore or purge it. With an If-Match header the movie is only deleted if it is still at that ETag; the header is required when the server runs with REQUIRE_IF_MATCH.
 // @Tags movies
 // @Accept json
 // @Produce json
 // @Param id path int true "Movie ID"
+// @Param If-Match header string false "ETags of the movie from GET /movies/{id}"
 // @Success 204
 // @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Failure 412 {object} object
+// @Failure 428 {object} object
 // @Router /movies/{id} [delete]
 func (h *MovieHandler) DeleteMovie(c *gin.Context) {
     idParam := c.Param("id")
@@ -144,8 +271,20 @@ func (h *MovieHandler) DeleteMovie(c *gin.Context) {
         return
     }
     
-    if err := h.movieService.DeleteMovie(uint(id)); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete movie"})
+    version, ok := h.ifMatchVersion(c, uint(id))
+    if !ok {
+        return
+    }
+    
+    if err := h.movieService.DeleteMovie(uint(id), version, c.GetUint("userID")); err != nil {
+        switch {
+        case errors.Is(err, services.ErrMovieNotFound):
+            c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+        case errors.Is(err, services.ErrMovieVersionMismatch):
+            c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Movie has been changed since it was read"})
+        default:
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete movie"})
+        }
         return
     }
     
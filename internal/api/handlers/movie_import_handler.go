@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/services"
+)
+
+// importContentTypes maps the content types of an import request body to
+// their format.
+var importContentTypes = map[string]string{
+	"text/csv":              models.CatalogFormatCSV,
+	"application/csv":       models.CatalogFormatCSV,
+	"application/x-ndjson":  models.CatalogFormatNDJSON,
+	"application/jsonl":     models.CatalogFormatNDJSON,
+	"application/jsonlines": models.CatalogFormatNDJSON,
+}
+
+// @Summary Import movies
+// @Description Create or update movies from a CSV or NDJSON request body. CSV needs a header with title, director and year columns and may add plot and genre_ids (IDs separated by |). Each NDJSON line is a movie like the body of POST /movies. A row updates the movie with the same title (ignoring case) and year, if any. Invalid rows are skipped and reported with their line number; the other rows are imported in batches. With dry_run nothing is saved.
+// @Tags movies
+// @Accept text/csv,application/x-ndjson
+// @Produce json
+// @Param format query string false "Body format, taken from Content-Type by default" Enums(csv, ndjson)
+// @Param dry_run query bool false "Validate and report without saving"
+// @Param batch_size query int false "Rows per transaction" minimum(1) maximum(5000) default(500)
+// @Success 200 {object} models.MovieImportReport
+// @Failure 400 {object} object
+// @Failure 415 {object} object
+// @Router /movies/import [post]
+func (h *MovieHandler) ImportMovies(c *gin.Context) {
+	var query models.MovieImportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := query.Format
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		format = importContentTypes[mediaType]
+	}
+	if format == "" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Send text/csv or application/x-ndjson, or set the format parameter"})
+		return
+	}
+
+	report, err := h.movieService.ImportMovies(c.Request.Context(), c.Request.Body, format, query.DryRun, query.BatchSize, c.GetUint("userID"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidImportHeader) || report == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// Batches written before the error are kept, so report them.
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "report": report})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
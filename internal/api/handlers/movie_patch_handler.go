@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mehmonov/movies-crud/internal/services"
+	"github.com/mehmonov/movies-crud/pkg/jsonpatch"
+)
+
+// @Summary Patch a movie
+// @Description Change some fields of a movie with a JSON Merge Patch (RFC 7396, application/merge-patch+json) or a JSON Patch (RFC 6902, application/json-patch+json). The patch applies to the document {"title", "director", "year", "plot", "genre_ids"}, so fields can also be cleared, e.g. {"plot": null}. The result must be a valid movie, and is saved in full or not at all. If-Match works as for PUT.
+// @Tags movies
+// @Accept application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param If-Match header string false "ETags of the movie from GET /movies/{id}"
+// @Param patch body object true "Merge patch object or array of JSON Patch operations"
+// @Success 200 {object} models.Movie
+// @Header 200 {string} ETag "Entity tag of the updated movie"
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Failure 409 {object} object
+// @Failure 412 {object} object
+// @Failure 415 {object} object
+// @Failure 422 {object} object
+// @Failure 428 {object} object
+// @Router /movies/{id} [patch]
+func (h *MovieHandler) PatchMovie(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	version, ok := h.ifMatchVersion(c, uint(id))
+	if !ok {
+		return
+	}
+
+	patchType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.movieService.PatchMovie(uint(id), version, patchType, patch, c.GetUint("userID")); err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnsupportedPatchType):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Send application/merge-patch+json or application/json-patch+json"})
+		case errors.Is(err, jsonpatch.ErrInvalidPatch):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, jsonpatch.ErrConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidMoviePatch), errors.Is(err, services.ErrGenreNotFound):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrMovieNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		case errors.Is(err, services.ErrMovieVersionMismatch):
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Movie has been changed since it was read"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update movie"})
+		}
+		return
+	}
+
+	movie, err := h.movieService.GetMovieByID(uint(id))
+	if err != nil || movie == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve movie"})
+		return
+	}
+	c.Header("ETag", movieETag(movie))
+	c.JSON(http.StatusOK, movie)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mehmonov/movies-crud/internal/services"
+)
+
+// @Summary Get the edit history of a movie
+// @Description Get every recorded create, update, delete and revert of a movie, newest first, with the user who made it and the fields that changed. The history of a deleted movie can still be read.
+// @Tags movies
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Success 200 {array} models.MovieRevision
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /movies/{id}/history [get]
+func (h *MovieHandler) GetMovieHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	revisions, err := h.movieService.GetMovieRevisions(uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrMovieNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve movie history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// @Summary Revert a movie to a revision
+// @Description Restore the title, director, year, plot and genres a movie had after the given revision. The revert is recorded as a new revision. If genres of the revision have been deleted since, the revert fails with 409 and lists them in missing_genre_ids, unless drop_missing_genres is set and they are left out. With an If-Match header the revert is only made if the movie is still at that ETag; the header is required when the server runs with REQUIRE_IF_MATCH.
+// @Tags movies
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param revisionId path int true "Revision ID"
+// @Param drop_missing_genres query bool false "Leave out genres of the revision that have been deleted"
+// @Param If-Match header string false "ETags of the movie from GET /movies/{id}"
+// @Success 200 {object} models.Movie
+// @Header 200 {string} ETag "Entity tag of the reverted movie"
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Failure 409 {object} object
+// @Failure 412 {object} object
+// @Failure 428 {object} object
+// @Router /movies/{id}/history/{revisionId}/revert [post]
+func (h *MovieHandler) RevertMovie(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	revisionID, err := strconv.ParseUint(c.Param("revisionId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var dropMissingGenres bool
+	if value := c.Query("drop_missing_genres"); value != "" {
+		dropMissingGenres, err = strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "drop_missing_genres must be true or false"})
+			return
+		}
+	}
+
+	version, ok := h.ifMatchVersion(c, uint(id))
+	if !ok {
+		return
+	}
+
+	movie, err := h.movieService.RevertMovie(uint(id), uint(revisionID), version, dropMissingGenres, c.GetUint("userID"))
+	if err != nil {
+		var missing *services.MissingGenresError
+		switch {
+		case errors.As(err, &missing):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "missing_genre_ids": missing.IDs})
+		case errors.Is(err, services.ErrMovieNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		case errors.Is(err, services.ErrRevisionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrMovieVersionMismatch):
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Movie has been changed since it was read"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revert movie"})
+		}
+		return
+	}
+
+	c.Header("ETag", movieETag(movie))
+	c.JSON(http.StatusOK, movie)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/services"
+)
+
+type PersonHandler struct {
+	personService *services.PersonService
+}
+
+func NewPersonHandler(personService *services.PersonService) *PersonHandler {
+	return &PersonHandler{
+		personService: personService,
+	}
+}
+
+// @Summary Get all people
+// @Description Get a paginated list of people ordered by name
+// @Tags people
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number (starts at 1)" minimum(1) default(1)
+// @Param limit query int false "People per page" minimum(1) maximum(100) default(20)
+// @Param name query string false "Case-insensitive substring of the name"
+// @Success 200 {object} models.PersonListResponse
+// @Failure 400 {object} object
+// @Router /people [get]
+func (h *PersonHandler) GetAllPeople(c *gin.Context) {
+	var query models.PersonListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	people, total, err := h.personService.GetAllPeople(&query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve people"})
+		return
+	}
+
+	response := models.PersonListResponse{
+		Data:  people,
+		Total: total,
+		Page:  query.Page,
+		Limit: query.Limit,
+	}
+	if int64(query.Page*query.Limit) < total {
+		response.Next = pageLink(c, query.Page+1, query.Limit)
+	}
+	if query.Page > 1 {
+		response.Prev = pageLink(c, query.Page-1, query.Limit)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Get a person by ID
+// @Description Get details of a specific person
+// @Tags people
+// @Accept json
+// @Produce json
+// @Param id path int true "Person ID"
+// @Success 200 {object} models.Person
+// @Failure 404 {object} object
+// @Router /people/{id} [get]
+func (h *PersonHandler) GetPersonByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	person, err := h.personService.GetPersonByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve person"})
+		return
+	}
+
+	if person == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, person)
+}
+
+// @Summary Get a person's filmography
+// @Description Get every credit of a person with its movie, newest movie first
+// @Tags people
+// @Accept json
+// @Produce json
+// @Param id path int true "Person ID"
+// @Success 200 {array} models.Credit
+// @Failure 404 {object} object
+// @Router /people/{id}/credits [get]
+func (h *PersonHandler) GetFilmography(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	credits, err := h.personService.GetFilmography(uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrPersonNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve filmography"})
+		return
+	}
+
+	c.JSON(http.StatusOK, credits)
+}
+
+// @Summary Create a new person
+// @Description Add a new person who can be credited on movies
+// @Tags people
+// @Accept json
+// @Produce json
+// @Param person body models.PersonRequest true "Person information"
+// @Success 201 {object} models.Person
+// @Failure 400 {object} object
+// @Router /people [post]
+func (h *PersonHandler) CreatePerson(c *gin.Context) {
+	var req models.PersonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	person, err := h.personService.CreatePerson(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create person"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, person)
+}
+
+// @Summary Update a person
+// @Description Rename a person; the director shown on their movies follows
+// @Tags people
+// @Accept json
+// @Produce json
+// @Param id path int true "Person ID"
+// @Param person body models.PersonRequest true "Person information"
+// @Success 200 {object} models.Person
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /people/{id} [put]
+func (h *PersonHandler) UpdatePerson(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req models.PersonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	person, err := h.personService.UpdatePerson(uint(id), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrPersonNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update person"})
+		return
+	}
+
+	c.JSON(http.StatusOK, person)
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/services"
+)
+
+type ReviewHandler struct {
+	reviewService *services.ReviewService
+}
+
+func NewReviewHandler(reviewService *services.ReviewService) *ReviewHandler {
+	return &ReviewHandler{
+		reviewService: reviewService,
+	}
+}
+
+// @Summary Get a movie's reviews
+// @Description Get a paginated list of reviews for a movie, newest first
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param page query int false "Page number (starts at 1)" minimum(1) default(1)
+// @Param limit query int false "Reviews per page" minimum(1) maximum(100) default(20)
+// @Success 200 {object} models.ReviewListResponse
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /movies/{id}/reviews [get]
+func (h *ReviewHandler) GetMovieReviews(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var query models.ReviewListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reviews, total, err := h.reviewService.GetMovieReviews(uint(id), &query)
+	if err != nil {
+		if errors.Is(err, services.ErrMovieNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reviews"})
+		return
+	}
+
+	response := models.ReviewListResponse{
+		Data:  reviews,
+		Total: total,
+		Page:  query.Page,
+		Limit: query.Limit,
+	}
+	if int64(query.Page*query.Limit) < total {
+		response.Next = pageLink(c, query.Page+1, query.Limit)
+	}
+	if query.Page > 1 {
+		response.Prev = pageLink(c, query.Page-1, query.Limit)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Review a movie
+// @Description Score a movie from 1 to 10 with an optional text. Each user can review a movie once.
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param review body models.ReviewRequest true "Review"
+// @Success 201 {object} models.Review
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Failure 409 {object} object
+// @Router /movies/{id}/reviews [post]
+func (h *ReviewHandler) CreateReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req models.ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review, err := h.reviewService.CreateReview(uint(id), c.GetUint("userID"), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMovieNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		case errors.Is(err, services.ErrReviewExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create review"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, review)
+}
+
+// @Summary Update your review
+// @Description Change the score or text of your own review of a movie
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param review body models.ReviewRequest true "Review"
+// @Success 200 {object} models.Review
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /movies/{id}/reviews [put]
+func (h *ReviewHandler) UpdateReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req models.ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	review, err := h.reviewService.UpdateReview(uint(id), c.GetUint("userID"), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMovieNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		case errors.Is(err, services.ErrReviewNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// @Summary Delete your review
+// @Description Remove your own review of a movie
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Success 204
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /movies/{id}/reviews [delete]
+func (h *ReviewHandler) DeleteReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	if err := h.reviewService.DeleteReview(uint(id), c.GetUint("userID")); err != nil {
+		switch {
+		case errors.Is(err, services.ErrMovieNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		case errors.Is(err, services.ErrReviewNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete review"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/services"
+	"github.com/mehmonov/movies-crud/pkg/subtitle"
+)
+
+type SubtitleHandler struct {
+	subtitleService *services.SubtitleService
+}
+
+func NewSubtitleHandler(subtitleService *services.SubtitleService) *SubtitleHandler {
+	return &SubtitleHandler{
+		subtitleService: subtitleService,
+	}
+}
+
+// @Summary Get subtitle tracks of a movie
+// @Description Get the subtitle tracks of a movie, ordered by language
+// @Tags subtitles
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Success 200 {array} models.SubtitleTrack
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /movies/{id}/subtitles [get]
+func (h *SubtitleHandler) GetMovieSubtitles(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	tracks, err := h.subtitleService.GetMovieSubtitles(uint(movieID))
+	if err != nil {
+		if errors.Is(err, services.ErrMovieNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subtitles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tracks)
+}
+
+// @Summary Get a subtitle track as WebVTT
+// @Description Get the subtitles of a movie in one language as WebVTT, converted from SRT if needed and shifted by the track's offset. The offset query parameter shifts the cues further.
+// @Tags subtitles
+// @Produce text/vtt
+// @Param id path int true "Movie ID"
+// @Param language path string true "Language tag, e.g. en or pt-BR"
+// @Param offset query int false "Extra timing offset in milliseconds"
+// @Success 200 {string} string
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /movies/{id}/subtitles/{language} [get]
+func (h *SubtitleHandler) GetSubtitle(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var offset int64
+	if value := c.Query("offset"); value != "" {
+		offset, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a number of milliseconds"})
+			return
+		}
+	}
+
+	_, vtt, err := h.subtitleService.GetVTT(c.Request.Context(), uint(movieID), c.Param("language"), time.Duration(offset)*time.Millisecond)
+	if err != nil {
+		if errors.Is(err, services.ErrSubtitleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read subtitles"})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/vtt; charset=utf-8", vtt)
+}
+
+// @Summary Upload a subtitle track
+// @Description Upload an SRT or WebVTT file as the "file" field of a multipart form. It replaces the movie's track in that language. Malformed cues are reported with their line numbers.
+// @Tags subtitles
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param language path string true "Language tag, e.g. en or pt-BR"
+// @Param file formData file true "SRT or WebVTT file"
+// @Param label formData string false "Label shown in players, e.g. English (CC)"
+// @Param offset_ms formData int false "Timing offset in milliseconds"
+// @Success 200 {object} models.SubtitleTrack
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Failure 413 {object} object
+// @Failure 422 {object} object
+// @Router /movies/{id}/subtitles/{language} [put]
+func (h *SubtitleHandler) SetSubtitle(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.subtitleService.MaxUploadSize()+1<<20)
+
+	var req models.SubtitleUploadRequest
+	if err := c.ShouldBind(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrSubtitleTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing file field"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read subtitle file"})
+		return
+	}
+	defer file.Close()
+
+	track, err := h.subtitleService.SetSubtitle(c.Request.Context(), uint(movieID), c.Param("language"), &req, file)
+	if err != nil {
+		h.respondSubtitleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, track)
+}
+
+// @Summary Update a subtitle track
+// @Description Change the label or timing offset of a subtitle track
+// @Tags subtitles
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param language path string true "Language tag, e.g. en or pt-BR"
+// @Param track body models.UpdateSubtitleRequest true "Track fields"
+// @Success 200 {object} models.SubtitleTrack
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /movies/{id}/subtitles/{language} [patch]
+func (h *SubtitleHandler) UpdateSubtitle(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req models.UpdateSubtitleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	track, err := h.subtitleService.UpdateSubtitle(uint(movieID), c.Param("language"), &req)
+	if err != nil {
+		h.respondSubtitleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, track)
+}
+
+// @Summary Delete a subtitle track
+// @Description Remove the movie's subtitles in one language
+// @Tags subtitles
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param language path string true "Language tag, e.g. en or pt-BR"
+// @Success 204
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /movies/{id}/subtitles/{language} [delete]
+func (h *SubtitleHandler) DeleteSubtitle(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	if err := h.subtitleService.DeleteSubtitle(c.Request.Context(), uint(movieID), c.Param("language")); err != nil {
+		h.respondSubtitleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SubtitleHandler) respondSubtitleError(c *gin.Context, err error) {
+	var cueErrors subtitle.ErrorList
+	switch {
+	case errors.As(err, &cueErrors):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid subtitle file", "errors": cueErrors})
+	case errors.Is(err, services.ErrMovieNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+	case errors.Is(err, services.ErrSubtitleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidLanguage), errors.Is(err, services.ErrSubtitleOffsetRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSubtitleTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save subtitles"})
+	}
+}
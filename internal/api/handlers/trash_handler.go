@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/services"
+)
+
+type TrashHandler struct {
+	trashService *services.TrashService
+}
+
+func NewTrashHandler(trashService *services.TrashService) *TrashHandler {
+	return &TrashHandler{
+		trashService: trashService,
+	}
+}
+
+// @Summary Get deleted movies
+// @Description Get a paginated list of the movies in the trash, most recently deleted first
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number (starts at 1)" minimum(1) default(1)
+// @Param limit query int false "Movies per page" minimum(1) maximum(100) default(20)
+// @Success 200 {object} models.DeletedMovieListResponse
+// @Failure 400 {object} object
+// @Router /movies/trash [get]
+func (h *TrashHandler) GetDeletedMovies(c *gin.Context) {
+	var query models.DeletedMovieListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	movies, total, err := h.trashService.GetDeletedMovies(&query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve deleted movies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.DeletedMovieListResponse{
+		Data:  movies,
+		Total: total,
+		Page:  query.Page,
+		Limit: query.Limit,
+	})
+}
+
+// @Summary Restore deleted movies
+// @Description Take one or more movies out of the trash. IDs that are not in the trash are listed in not_found.
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param request body models.TrashRequest true "IDs of the movies to restore"
+// @Success 200 {object} models.TrashResponse
+// @Failure 400 {object} object
+// @Router /movies/trash/restore [post]
+func (h *TrashHandler) RestoreMovies(c *gin.Context) {
+	var req models.TrashRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.trashService.RestoreMovies(req.IDs, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore movies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Purge deleted movies
+// @Description Permanently delete one or more movies from the trash, with their credits, reviews, watched history, media, images, subtitles and edit history. IDs that are not in the trash are listed in not_found.
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param request body models.TrashRequest true "IDs of the movies to purge"
+// @Success 200 {object} models.TrashResponse
+// @Failure 400 {object} object
+// @Router /movies/trash/purge [post]
+func (h *TrashHandler) PurgeMovies(c *gin.Context) {
+	var req models.TrashRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.trashService.PurgeMovies(c.Request.Context(), req.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge movies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -1,25 +1,26 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/mehmonov/movies-crud/internal/models"
 	"github.com/mehmonov/movies-crud/internal/services"
-	"github.com/mehmonov/movies-crud/pkg/auth"
 )
 
 type UserHandler struct {
-	userService *services.UserService
-	jwtService  *auth.JWTService
+	userService  *services.UserService
+	tokenService *services.TokenService
 }
 
-func NewUserHandler(userService *services.UserService, jwtService *auth.JWTService) *UserHandler {
+func NewUserHandler(userService *services.UserService, tokenService *services.TokenService) *UserHandler {
 	return &UserHandler{
-		userService: userService,
-		jwtService:  jwtService,
+		userService:  userService,
+		tokenService: tokenService,
 	}
 }
 
@@ -75,22 +76,103 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.jwtService.GenerateToken(user.ID)
+	response, err := h.tokenService.IssueTokens(user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
-	// Create a safe user response without password 
-	safeUser := &models.User{
-		ID:        user.ID,
-		Username:  user.Username,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access token and refresh token. Each refresh token can only be used once; presenting a used one again revokes the whole session.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body models.RefreshRequest true "Refresh token"
+// @Success 200 {object} models.AuthResponse
+// @Failure 400 {object} object
+// @Failure 401 {object} object
+// @Router /auth/refresh [post]
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.tokenService.Refresh(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Logout user
+// @Description Revoke the refresh token and every token rotated from it. Access tokens already issued stay valid until they expire.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body models.RefreshRequest true "Refresh token"
+// @Success 204
+// @Failure 400 {object} object
+// @Router /auth/logout [post]
+func (h *UserHandler) Logout(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.tokenService.Revoke(req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Change a user's role
+// @Description Set the role of a user to admin, editor or viewer. Only admins can do this, and the new role applies to tokens issued after the change.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role body models.UpdateRoleRequest true "New role"
+// @Success 200 {object} models.User
+// @Failure 400 {object} object
+// @Failure 403 {object} object
+// @Failure 404 {object} object
+// @Router /users/{id}/role [put]
+func (h *UserHandler) UpdateUserRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userService.UpdateUserRole(uint(id), req.Role)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
 	}
 
-	c.JSON(http.StatusOK, models.AuthResponse{
-		Token: token,
-		User:  safeUser,
-	})
+	c.JSON(http.StatusOK, user)
 }
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/services"
+)
+
+type WatchlistHandler struct {
+	watchlistService *services.WatchlistService
+}
+
+func NewWatchlistHandler(watchlistService *services.WatchlistService) *WatchlistHandler {
+	return &WatchlistHandler{
+		watchlistService: watchlistService,
+	}
+}
+
+// @Summary Get your watchlist
+// @Description Get the movies on the authenticated user's watchlist in order
+// @Tags me
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.WatchlistItem
+// @Router /me/watchlist [get]
+func (h *WatchlistHandler) GetWatchlist(c *gin.Context) {
+	items, err := h.watchlistService.GetWatchlist(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve watchlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// @Summary Add a movie to your watchlist
+// @Description Append a movie to the end of the authenticated user's watchlist
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param item body models.WatchlistRequest true "Movie to add"
+// @Success 201 {object} models.WatchlistItem
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Failure 409 {object} object
+// @Router /me/watchlist [post]
+func (h *WatchlistHandler) AddToWatchlist(c *gin.Context) {
+	var req models.WatchlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.watchlistService.AddToWatchlist(c.GetUint("userID"), req.MovieID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMovieNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		case errors.Is(err, services.ErrWatchlistItemExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add to watchlist"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// @Summary Reorder your watchlist
+// @Description Set the order of the authenticated user's watchlist. movie_ids must list every movie on it exactly once.
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param order body models.WatchlistOrderRequest true "Movie IDs in the new order"
+// @Success 200 {array} models.WatchlistItem
+// @Failure 400 {object} object
+// @Router /me/watchlist/order [put]
+func (h *WatchlistHandler) ReorderWatchlist(c *gin.Context) {
+	var req models.WatchlistOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, err := h.watchlistService.ReorderWatchlist(c.GetUint("userID"), req.MovieIDs)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidWatchlistOrder) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder watchlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// @Summary Remove a movie from your watchlist
+// @Description Remove a movie from the authenticated user's watchlist
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param movieId path int true "Movie ID"
+// @Success 204
+// @Failure 400 {object} object
+// @Failure 404 {object} object
+// @Router /me/watchlist/{movieId} [delete]
+func (h *WatchlistHandler) RemoveFromWatchlist(c *gin.Context) {
+	movieID, err := strconv.ParseUint(c.Param("movieId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	if err := h.watchlistService.RemoveFromWatchlist(c.GetUint("userID"), uint(movieID)); err != nil {
+		if errors.Is(err, services.ErrWatchlistItemNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove from watchlist"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
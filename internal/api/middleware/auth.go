@@ -26,14 +26,15 @@ func AuthMiddleware(jwtService *auth.JWTService) gin.HandlerFunc {
 		}
 
 		token := parts[1]
-		userID, err := jwtService.ValidateToken(token)
+		claims, err := jwtService.ValidateToken(token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
 			return
 		}
 
-		c.Set("userID", userID)
+		c.Set("userID", claims.UserID)
+		c.Set("role", claims.Role)
 		c.Next()
 	}
 }
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mehmonov/movies-crud/pkg/auth"
+)
+
+// SignedURLOrAuth lets through requests whose URL was signed by signer.
+// Requests without a signature must carry a bearer token instead, as checked
+// by AuthMiddleware.
+func SignedURLOrAuth(signer *auth.URLSigner, jwtService *auth.JWTService) gin.HandlerFunc {
+	authenticate := AuthMiddleware(jwtService)
+
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		if !query.Has("signature") {
+			authenticate(c)
+			return
+		}
+
+		if err := signer.Verify(c.Request.URL.Path, query); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -8,23 +8,49 @@ import (
 	"github.com/mehmonov/movies-crud/config"
 	"github.com/mehmonov/movies-crud/internal/api/handlers"
 	"github.com/mehmonov/movies-crud/internal/api/middleware"
+	"github.com/mehmonov/movies-crud/internal/models"
 	"github.com/mehmonov/movies-crud/internal/services"
 	"github.com/mehmonov/movies-crud/pkg/auth"
 )
 
 func NewRouter(
-	cfg *config.Config,
+	jwtService *auth.JWTService,
 	movieService *services.MovieService,
 	userService *services.UserService,
+	tokenService *services.TokenService,
+	genreService *services.GenreService,
+	personService *services.PersonService,
+	creditService *services.CreditService,
+	reviewService *services.ReviewService,
+	watchlistService *services.WatchlistService,
+	historyService *services.HistoryService,
+	movieSearcher services.MovieSearcher,
+	mediaService *services.MediaService,
+	hlsService *services.HLSService,
+	imageService *services.ImageService,
+	subtitleService *services.SubtitleService,
+	trashService *services.TrashService,
+	urlSigner *auth.URLSigner,
+	cfg *config.Config,
 ) *gin.Engine {
 	router := gin.Default()
 
-	jwtService := auth.NewJWTService(cfg.JWTSecret)
-
-	movieHandler := handlers.NewMovieHandler(movieService)
-	userHandler := handlers.NewUserHandler(userService, jwtService)
+	movieHandler := handlers.NewMovieHandler(movieService, movieSearcher, cfg.Movies.RequireIfMatch)
+	userHandler := handlers.NewUserHandler(userService, tokenService)
+	genreHandler := handlers.NewGenreHandler(genreService)
+	personHandler := handlers.NewPersonHandler(personService)
+	creditHandler := handlers.NewCreditHandler(creditService)
+	reviewHandler := handlers.NewReviewHandler(reviewService)
+	watchlistHandler := handlers.NewWatchlistHandler(watchlistService)
+	historyHandler := handlers.NewHistoryHandler(historyService)
+	mediaHandler := handlers.NewMediaHandler(mediaService, hlsService, urlSigner, cfg.Media.RequireSignedURL)
+	imageHandler := handlers.NewImageHandler(imageService)
+	subtitleHandler := handlers.NewSubtitleHandler(subtitleService)
+	trashHandler := handlers.NewTrashHandler(trashService)
+	jwksHandler := handlers.NewJWKSHandler(jwtService)
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
 
 	api := router.Group("/api/v1")
 	{
@@ -32,21 +58,134 @@ func NewRouter(
 		{
 			auth.POST("/register", userHandler.Register)
 			auth.POST("/login", userHandler.Login)
+			auth.POST("/refresh", userHandler.Refresh)
+			auth.POST("/logout", userHandler.Logout)
+			auth.GET("/jwks.json", jwksHandler.GetJWKS)
 		}
 
 		movies := api.Group("/movies")
 		{
-			movies.GET("", movieHandler.GetAllMovies)     // Public endpoint
-			movies.GET("/:id", movieHandler.GetMovieByID) // Public endpoint
+			movies.GET("", movieHandler.GetAllMovies)                           // Public endpoint
+			movies.GET("/search", movieHandler.SearchMovies)                    // Public endpoint
+			movies.GET("/:id", movieHandler.GetMovieByID)                       // Public endpoint
+			movies.GET("/:id/credits", creditHandler.GetMovieCredits)           // Public endpoint
+			movies.GET("/:id/reviews", reviewHandler.GetMovieReviews)           // Public endpoint
+			movies.GET("/:id/media", mediaHandler.GetMovieMedia)                // Public endpoint
+			movies.GET("/:id/images/:imageId/:size", imageHandler.GetImage)     // Public endpoint
+			movies.GET("/:id/subtitles", subtitleHandler.GetMovieSubtitles)     // Public endpoint
+			movies.GET("/:id/subtitles/:language", subtitleHandler.GetSubtitle) // Public endpoint
+
+			// Videos can be streamed by anyone unless signed URLs are required
+			var streamAuth []gin.HandlerFunc
+			if cfg.Media.RequireSignedURL {
+				streamAuth = append(streamAuth, middleware.SignedURLOrAuth(urlSigner, jwtService))
+			}
+			stream := movies.Group("/:id/media/:mediaId", streamAuth...)
+			{
+				stream.GET("/stream", mediaHandler.StreamMedia)
+				stream.HEAD("/stream", mediaHandler.StreamMedia)
+				stream.GET("/hls/:file", mediaHandler.GetHLSFile)
+			}
 
 			// Protected movie routes (with auth middleware)
 			movies.Use(middleware.AuthMiddleware(jwtService))
 			{
-				movies.POST("", movieHandler.CreateMovie)
-				movies.PUT("/:id", movieHandler.UpdateMovie)
-				movies.DELETE("/:id", movieHandler.DeleteMovie)
+				movies.POST("/:id/reviews", reviewHandler.CreateReview)
+				movies.PUT("/:id/reviews", reviewHandler.UpdateReview)
+				movies.DELETE("/:id/reviews", reviewHandler.DeleteReview)
+				movies.GET("/:id/media/:mediaId/stream-url", mediaHandler.GetStreamURL)
+			}
+
+			// Catalog changes are limited to editors and admins
+			editors := movies.Group("", middleware.RequireRole(models.RoleAdmin, models.RoleEditor))
+			{
+				editors.POST("", movieHandler.CreateMovie)
+				editors.PUT("/:id", movieHandler.UpdateMovie)
+				editors.PATCH("/:id", movieHandler.PatchMovie)
+				editors.DELETE("/:id", movieHandler.DeleteMovie)
+				editors.GET("/:id/history", movieHandler.GetMovieHistory)
+				editors.POST("/:id/history/:revisionId/revert", movieHandler.RevertMovie)
+				editors.POST("/:id/credits", creditHandler.AddCredit)
+				editors.DELETE("/:id/credits/:creditId", creditHandler.DeleteCredit)
+				editors.POST("/:id/media", mediaHandler.UploadMedia)
+				editors.DELETE("/:id/media/:mediaId", mediaHandler.DeleteMedia)
+				editors.POST("/:id/media/uploads", mediaHandler.CreateUploadSession)
+				editors.GET("/:id/media/uploads/:uploadId", mediaHandler.GetUploadSession)
+				editors.PATCH("/:id/media/uploads/:uploadId", mediaHandler.UploadChunk)
+				editors.PUT("/:id/images/:kind", imageHandler.SetImage)
+				editors.DELETE("/:id/images/:kind", imageHandler.DeleteImage)
+				editors.PUT("/:id/subtitles/:language", subtitleHandler.SetSubtitle)
+				editors.PATCH("/:id/subtitles/:language", subtitleHandler.UpdateSubtitle)
+				editors.DELETE("/:id/subtitles/:language", subtitleHandler.DeleteSubtitle)
+			}
+
+			// Bulk imports and exports and the trash are limited to admins
+			admins := movies.Group("", middleware.RequireRole(models.RoleAdmin))
+			{
+				admins.POST("/import", movieHandler.ImportMovies)
+				admins.GET("/export", movieHandler.ExportMovies)
+				admins.GET("/trash", trashHandler.GetDeletedMovies)
+				admins.POST("/trash/restore", trashHandler.RestoreMovies)
+				admins.POST("/trash/purge", trashHandler.PurgeMovies)
+			}
+		}
+
+		genres := api.Group("/genres")
+		{
+			genres.GET("", genreHandler.GetAllGenres)     // Public endpoint
+			genres.GET("/:id", genreHandler.GetGenreByID) // Public endpoint
+
+			// Protected genre routes (editors and admins only)
+			genres.Use(
+				middleware.AuthMiddleware(jwtService),
+				middleware.RequireRole(models.RoleAdmin, models.RoleEditor),
+			)
+			{
+				genres.POST("", genreHandler.CreateGenre)
+				genres.PUT("/:id", genreHandler.UpdateGenre)
+				genres.DELETE("/:id", genreHandler.DeleteGenre)
+			}
+		}
+
+		people := api.Group("/people")
+		{
+			people.GET("", personHandler.GetAllPeople)               // Public endpoint
+			people.GET("/:id", personHandler.GetPersonByID)          // Public endpoint
+			people.GET("/:id/credits", personHandler.GetFilmography) // Public endpoint
+
+			// Protected people routes (editors and admins only)
+			people.Use(
+				middleware.AuthMiddleware(jwtService),
+				middleware.RequireRole(models.RoleAdmin, models.RoleEditor),
+			)
+			{
+				people.POST("", personHandler.CreatePerson)
+				people.PUT("/:id", personHandler.UpdatePerson)
 			}
 		}
+
+		users := api.Group("/users")
+		users.Use(
+			middleware.AuthMiddleware(jwtService),
+			middleware.RequireRole(models.RoleAdmin),
+		)
+		{
+			users.PUT("/:id/role", userHandler.UpdateUserRole)
+		}
+
+		// Routes for the authenticated user's own data
+		me := api.Group("/me")
+		me.Use(middleware.AuthMiddleware(jwtService))
+		{
+			me.GET("/watchlist", watchlistHandler.GetWatchlist)
+			me.POST("/watchlist", watchlistHandler.AddToWatchlist)
+			me.PUT("/watchlist/order", watchlistHandler.ReorderWatchlist)
+			me.DELETE("/watchlist/:movieId", watchlistHandler.RemoveFromWatchlist)
+
+			me.GET("/history", historyHandler.GetHistory)
+			me.POST("/history", historyHandler.AddWatchedEntry)
+			me.DELETE("/history/:entryId", historyHandler.DeleteWatchedEntry)
+		}
 	}
 
 	return router
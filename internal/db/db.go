@@ -1,29 +1,222 @@
 package db
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/glebarez/sqlite"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 
 	"github.com/mehmonov/movies-crud/config"
-	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/db/migrations"
 )
 
+// memoryDatabases numbers the in-memory SQLite databases, so that each
+// Open gets an empty one.
+var memoryDatabases atomic.Int64
+
+// NewDatabase connects to the configured database and applies the
+// migrations it is missing. With DB_MIGRATE_ON_START turned off it only
+// checks that there are none, and the schema is left to the migrate command.
 func NewDatabase(cfg *config.Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
+	db, err := Open(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	migrator, err := migrations.New(db, cfg.DB.Driver)
 	if err != nil {
 		return nil, err
 	}
+	if !cfg.DB.MigrateOnStart {
+		if err := migrator.Check(); err != nil {
+			return nil, fmt.Errorf("%w; run the migrate up command", err)
+		}
+		return db, nil
+	}
+	if _, err := migrator.Up(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// ReplicaResolver names the dbresolver of the read replicas. Queries read
+// from the replicas only when they opt in with
+// Clauses(dbresolver.Use(ReplicaResolver)); see repository.WithReplica.
+const ReplicaResolver = "replicas"
+
+const (
+	// retryDelay is the first pause between attempts to connect, doubled
+	// after each failure up to maxRetryDelay.
+	retryDelay    = 500 * time.Millisecond
+	maxRetryDelay = 10 * time.Second
+)
+
+// Open connects to the configured database without looking at its schema,
+// with connection pools sized as configured. While the database cannot be
+// reached or refuses connections, as PostgreSQL does while it boots, Open
+// keeps trying for DB_CONNECT_RETRY_TIMEOUT. Other errors, such as a wrong
+// password or a bad TLS setting, are returned right away.
+func Open(cfg *config.Config) (*gorm.DB, error) {
+	deadline := time.Now().Add(cfg.DB.ConnectRetryTimeout)
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		db, err := open(cfg)
+		if err == nil {
+			return db, nil
+		}
+		wait := min(delay, time.Until(deadline))
+		if cfg.DB.Driver != config.DBDriverPostgres || !retryable(err) || wait <= 0 {
+			return nil, err
+		}
+		log.Printf("Database not ready (attempt %d), retrying in %s: %v", attempt, wait.Round(time.Millisecond), err)
+		time.Sleep(wait)
+		delay = min(2*delay, maxRetryDelay)
+	}
+}
 
-	// Auto-migrate the schema
-	err = db.AutoMigrate(&models.Movie{}, &models.User{})
+// retryable reports whether err, from an attempt to connect, may go away
+// once the database server is up: the server cannot be reached yet, drops
+// the connection, or answers that it is starting or out of connections.
+func retryable(err error) bool {
+	var parseErr *pgconn.ParseConfigError
+	if errors.As(err, &parseErr) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// cannot_connect_now, too_many_connections and the connection
+		// exceptions of class 08.
+		return pgErr.Code == "57P03" || pgErr.Code == "53300" || strings.HasPrefix(pgErr.Code, "08")
+	}
+	// Not net.Error, which any syscall.Errno satisfies, such as that of a
+	// missing certificate file.
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	return errors.As(err, &opErr) || errors.As(err, &dnsErr) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// open makes one attempt of Open.
+func open(cfg *config.Config) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch cfg.DB.Driver {
+	case config.DBDriverPostgres:
+		dialector = postgres.Open(postgresDSN(cfg.DB))
+	case config.DBDriverSQLite:
+		dialector = sqlite.Open(sqliteDSN(cfg.DB.Path))
+	default:
+		return nil, fmt.Errorf("unknown DB_DRIVER %q: use %s or %s", cfg.DB.Driver, config.DBDriverPostgres, config.DBDriverSQLite)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
 
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.DB.Pool.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DB.Pool.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.DB.Pool.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.DB.Pool.ConnMaxIdleTime)
+
+	if len(cfg.DB.ReplicaDSNs) > 0 {
+		replicas := make([]gorm.Dialector, len(cfg.DB.ReplicaDSNs))
+		for i, dsn := range cfg.DB.ReplicaDSNs {
+			replicas[i] = postgres.Open(dsn)
+		}
+		if err := useReplicas(db, replicas, cfg.DB.Pool); err != nil {
+			sqlDB.Close()
+			return nil, fmt.Errorf("connect to replica: %w", err)
+		}
+	}
 	return db, nil
 }
+
+// useReplicas registers replicas as the ReplicaResolver of db, picked at
+// random for each query, with pools sized like the one of the primary.
+func useReplicas(db *gorm.DB, replicas []gorm.Dialector, pool config.DBPoolConfig) error {
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	}, ReplicaResolver)
+	if err := db.Use(resolver); err != nil {
+		return err
+	}
+	resolver.
+		SetMaxOpenConns(pool.MaxOpenConns).
+		SetMaxIdleConns(pool.MaxIdleConns).
+		SetConnMaxLifetime(pool.ConnMaxLifetime).
+		SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	return nil
+}
+
+// Close closes the connections of db to the primary and to the replicas,
+// and returns the errors of all of them.
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	errs := []error{sqlDB.Close()}
+	if resolver, ok := db.Config.Plugins[(&dbresolver.DBResolver{}).Name()].(*dbresolver.DBResolver); ok {
+		resolver.Call(func(pool gorm.ConnPool) error {
+			if closer, ok := pool.(interface{ Close() error }); ok && pool != gorm.ConnPool(sqlDB) {
+				errs = append(errs, closer.Close())
+			}
+			return nil
+		})
+	}
+	return errors.Join(errs...)
+}
+
+// postgresDSN is the libpq connection string of the primary.
+func postgresDSN(cfg config.DBConfig) string {
+	params := []struct{ key, value string }{
+		{"host", cfg.Host},
+		{"port", strconv.Itoa(cfg.Port)},
+		{"user", cfg.User},
+		{"password", cfg.Password},
+		{"dbname", cfg.Name},
+		{"sslmode", cfg.SSLMode},
+		{"sslrootcert", cfg.SSLRootCert},
+		{"sslcert", cfg.SSLCert},
+		{"sslkey", cfg.SSLKey},
+	}
+	var dsn []string
+	for _, param := range params {
+		if param.value != "" {
+			dsn = append(dsn, param.key+"="+dsnQuote(param.value))
+		}
+	}
+	return strings.Join(dsn, " ")
+}
+
+// dsnQuote quotes a value of a libpq connection string, so that it can
+// hold spaces, quotes and backslashes.
+func dsnQuote(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// sqliteDSN opens path with foreign keys enforced, or a new in-memory
+// database for ":memory:". The in-memory database is shared by the
+// connections of the pool and lives as long as one of them is open.
+func sqliteDSN(path string) string {
+	if path == ":memory:" {
+		return fmt.Sprintf("file:memory%d?mode=memory&cache=shared&_pragma=foreign_keys(1)", memoryDatabases.Add(1))
+	}
+	return "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+
+	"github.com/mehmonov/movies-crud/config"
+)
+
+func TestRetryable(t *testing.T) {
+	_, parseErr := pgconn.ParseConfig("sslmode=bogus")
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		{"unknown host", fmt.Errorf("connect: %w", &net.DNSError{Err: "no such host", Name: "db"}), true},
+		{"starting up", &pgconn.PgError{Code: "57P03"}, true},
+		{"too many connections", &pgconn.PgError{Code: "53300"}, true},
+		{"wrong password", fmt.Errorf("connect: %w", &pgconn.PgError{Code: "28P01"}), false},
+		{"no such database", &pgconn.PgError{Code: "3D000"}, false},
+		{"bad connection string", parseErr, false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, test := range tests {
+		if got := retryable(test.err); got != test.want {
+			t.Errorf("%s: retryable(%v) = %v, want %v", test.name, test.err, got, test.want)
+		}
+	}
+}
+
+// closedPort returns a local port that refuses connections.
+func closedPort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	return port
+}
+
+func postgresConfig(t *testing.T, retryTimeout time.Duration) *config.Config {
+	cfg := config.Default()
+	cfg.DB.Host = "127.0.0.1"
+	cfg.DB.Port = closedPort(t)
+	cfg.DB.SSLMode = "disable"
+	cfg.DB.ConnectRetryTimeout = retryTimeout
+	return cfg
+}
+
+func TestOpenRetriesUnreachableServer(t *testing.T) {
+	cfg := postgresConfig(t, 1200*time.Millisecond)
+	start := time.Now()
+	if _, err := Open(cfg); err == nil {
+		t.Fatal("Open of a closed port succeeded")
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Open gave up after %s, want it to retry for the timeout", elapsed)
+	}
+}
+
+func TestOpenDoesNotRetryConfigErrors(t *testing.T) {
+	cfg := postgresConfig(t, time.Minute)
+	cfg.DB.SSLMode = "verify-full"
+	cfg.DB.SSLRootCert = filepath.Join(t.TempDir(), "missing.pem")
+	start := time.Now()
+	if _, err := Open(cfg); err == nil {
+		t.Fatal("Open with a missing root certificate succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > retryDelay {
+		t.Errorf("Open retried a configuration error for %s", elapsed)
+	}
+}
+
+func TestCloseClosesReplicas(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(sqliteDSN(":memory:")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicas := []gorm.Dialector{sqlite.Open(sqliteDSN(":memory:")), sqlite.Open(sqliteDSN(":memory:"))}
+	if err := useReplicas(db, replicas, config.DBPoolConfig{MaxIdleConns: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Close(db); err != nil {
+		t.Fatal(err)
+	}
+	pools := 0
+	resolver := db.Config.Plugins[(&dbresolver.DBResolver{}).Name()].(*dbresolver.DBResolver)
+	resolver.Call(func(pool gorm.ConnPool) error {
+		pools++
+		if err := pool.(interface{ Ping() error }).Ping(); err == nil {
+			t.Errorf("pool %d is still open", pools)
+		}
+		return nil
+	})
+	if pools != 3 {
+		t.Errorf("checked %d pools, want the primary and 2 replicas", pools)
+	}
+}
@@ -0,0 +1,241 @@
+// Package migrations keeps the database schema up to date with versioned SQL
+// scripts. The scripts of each driver are embedded from a directory named
+// after it, as <version>_<name>.up.sql and <version>_<name>.down.sql. Up
+// applies the ones that have not been applied yet in version order, Down
+// reverts the latest ones, and the schema_migrations table records which
+// are applied.
+//
+// Each migration runs in a transaction together with its schema_migrations
+// row, so a failed migration leaves nothing behind. On PostgreSQL, Up and
+// Down hold an advisory lock while they run, so that replicas started
+// together migrate one after another; the later ones find nothing to do.
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/mehmonov/movies-crud/config"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var scripts embed.FS
+
+// advisoryLockKey identifies the PostgreSQL advisory lock held while
+// migrating. It spells "movies".
+const advisoryLockKey = 0x6d6f76696573
+
+// ErrPending is returned by Check when the database is missing migrations.
+var ErrPending = errors.New("database schema is not up to date")
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one step of the schema.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration and when it was applied, if it was.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+	Version   int64
+	AppliedAt time.Time
+}
+
+// Migrator applies the migrations of one driver to a database.
+type Migrator struct {
+	db         *gorm.DB
+	driver     string
+	migrations []Migration
+}
+
+// New returns a Migrator for db with the migrations of driver, one of the
+// config.DBDriver* values.
+func New(db *gorm.DB, driver string) (*Migrator, error) {
+	migrations, err := load(driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, driver: driver, migrations: migrations}, nil
+}
+
+// load reads the embedded migrations of driver, in version order.
+func load(driver string) ([]Migration, error) {
+	entries, err := fs.ReadDir(scripts, driver)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver %q", driver)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s/%s: name is not <version>_<name>.up.sql or .down.sql", driver, entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		script, err := fs.ReadFile(scripts, driver+"/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migrations %s/%s and %s share version %d", driver, migration.Name, match[2], version)
+		}
+		if match[3] == "up" {
+			migration.Up = string(script)
+		} else {
+			migration.Down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %s/%04d_%s needs both an up and a down script", driver, migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every migration that has not been applied yet and returns
+// them.
+func (m *Migrator) Up() ([]Migration, error) {
+	var done []Migration
+	err := m.locked(func(db *gorm.DB, applied map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return err
+				}
+				return tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+					migration.Version, migration.Name, time.Now().UTC()).Error
+			})
+			if err != nil {
+				return fmt.Errorf("applying migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the latest steps applied migrations, newest first, and
+// returns them.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(func(db *gorm.DB, applied map[int64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Down).Error; err != nil {
+					return err
+				}
+				return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("reverting migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			log.Printf("Reverted migration %04d_%s", migration.Version, migration.Name)
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status lists every migration in version order with when it was applied.
+func (m *Migrator) Status() ([]Status, error) {
+	var statuses []Status
+	err := m.locked(func(db *gorm.DB, applied map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			status := Status{Migration: migration}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Check returns ErrPending when some migrations have not been applied yet.
+func (m *Migrator) Check() error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+	var pending []Status
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, status)
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %d migrations to apply, starting with %04d_%s",
+			ErrPending, len(pending), pending[0].Version, pending[0].Name)
+	}
+	return nil
+}
+
+// locked calls fn with the versions applied so far, on a connection that
+// holds the migration lock.
+func (m *Migrator) locked(fn func(db *gorm.DB, applied map[int64]time.Time) error) error {
+	return m.db.Connection(func(db *gorm.DB) error {
+		if m.driver == config.DBDriverPostgres {
+			if err := db.Exec("SELECT pg_advisory_lock(?)", advisoryLockKey).Error; err != nil {
+				return fmt.Errorf("taking the migration lock: %w", err)
+			}
+			defer db.Exec("SELECT pg_advisory_unlock(?)", advisoryLockKey)
+		}
+
+		err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)`).Error
+		if err != nil {
+			return fmt.Errorf("creating schema_migrations: %w", err)
+		}
+
+		var rows []appliedMigration
+		if err := db.Raw("SELECT version, applied_at FROM schema_migrations").Scan(&rows).Error; err != nil {
+			return fmt.Errorf("reading schema_migrations: %w", err)
+		}
+		applied := make(map[int64]time.Time, len(rows))
+		for _, row := range rows {
+			applied[row.Version] = row.AppliedAt
+		}
+		return fn(db, applied)
+	})
+}
@@ -0,0 +1,156 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mehmonov/movies-crud/config"
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+func TestLoad(t *testing.T) {
+	for _, driver := range []string{config.DBDriverPostgres, config.DBDriverSQLite} {
+		migrations, err := load(driver)
+		if err != nil {
+			t.Fatalf("%s: %v", driver, err)
+		}
+		if len(migrations) == 0 || migrations[0].Version != 1 {
+			t.Errorf("%s: migrations start at %v", driver, migrations)
+		}
+	}
+}
+
+func TestSQLiteUpDown(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:migrations?mode=memory&cache=shared&_pragma=foreign_keys(1)"),
+		&gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := New(db, config.DBDriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrator.Check(); !errors.Is(err, ErrPending) {
+		t.Fatalf("Check on an empty database: got %v, want ErrPending", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.Movie{Title: "Heat", Year: 1995}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := migrator.Down(len(migrator.migrations))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != len(migrator.migrations) || db.Migrator().HasTable("movies") {
+		t.Fatalf("Down reverted %d migrations, movies left: %v", len(done), db.Migrator().HasTable("movies"))
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up after Down: %v", err)
+	}
+}
+
+// baselineMovie and baselineUser are the models of the first version, whose
+// tables AutoMigrate created before there were migrations.
+type baselineMovie struct {
+	ID        uint   `gorm:"primarykey"`
+	Title     string `gorm:"size:100;not null"`
+	Director  string `gorm:"size:100"`
+	Year      int    `gorm:"not null"`
+	Plot      string `gorm:"type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineMovie) TableName() string { return "movies" }
+
+type baselineUser struct {
+	ID        uint   `gorm:"primarykey"`
+	Username  string `gorm:"unique;not null"`
+	Password  string `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+// TestPostgresUpgradeFromBaseline migrates a database created by the first
+// version in a scratch schema of the PostgreSQL database named by
+// MIGRATIONS_TEST_POSTGRES_DSN, a key=value connection string, and is
+// skipped without it.
+func TestPostgresUpgradeFromBaseline(t *testing.T) {
+	dsn := os.Getenv("MIGRATIONS_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("MIGRATIONS_TEST_POSTGRES_DSN is not set")
+	}
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("migrations_test_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema+",public"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&baselineMovie{}, &baselineUser{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&baselineMovie{Title: "Heat", Director: "Michael Mann", Year: 1995}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&baselineUser{Username: "neil", Password: "x"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	migrator, err := New(db, config.DBDriverPostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	var movie models.Movie
+	if err := db.Preload("Credits").First(&movie).Error; err != nil {
+		t.Fatal(err)
+	}
+	if movie.Version != 1 || movie.Votes != 0 || len(movie.Credits) != 1 {
+		t.Errorf("got version %d, %d votes, %d credits; want 1, 0, 1", movie.Version, movie.Votes, len(movie.Credits))
+	}
+	var user models.User
+	if err := db.First(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != models.RoleViewer {
+		t.Errorf("got role %q, want %q", user.Role, models.RoleViewer)
+	}
+	if err := db.Create(&models.MediaFile{MovieID: movie.ID, StorageKey: "k", ContentType: "video/mp4", SHA256: "s", HLSStatus: models.HLSStatusQueued}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := migrator.Down(len(migrator.migrations)); err == nil || !strings.Contains(err.Error(), "cannot be reverted") {
+		t.Errorf("Down past the backfill: got %v, want it to refuse", err)
+	}
+}
@@ -0,0 +1,220 @@
+-- The schema as AutoMigrate created it before migrations were introduced.
+-- Every statement is skipped when its table, column or index already
+-- exists, so databases created by AutoMigrate are taken over. Columns that
+-- were added to a table after it was first created are added separately,
+-- for databases created by an older version, down to the first one, which
+-- only had movies and users.
+
+CREATE TABLE IF NOT EXISTS "genres" (
+    "id" bigserial,
+    "name" varchar(50) NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_genres_name" UNIQUE ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "movies" (
+    "id" bigserial,
+    "title" varchar(100) NOT NULL,
+    "director" varchar(100),
+    "year" bigint NOT NULL,
+    "plot" text,
+    "rating" decimal NOT NULL DEFAULT 0,
+    "votes" bigint NOT NULL DEFAULT 0,
+    "version" bigint NOT NULL DEFAULT 1,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_movies_deleted_at" ON "movies" ("deleted_at");
+ALTER TABLE "movies" ADD COLUMN IF NOT EXISTS "rating" decimal NOT NULL DEFAULT 0;
+ALTER TABLE "movies" ADD COLUMN IF NOT EXISTS "votes" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "movies" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS "movie_genres" (
+    "movie_id" bigint,
+    "genre_id" bigint,
+    PRIMARY KEY ("movie_id","genre_id"),
+    CONSTRAINT "fk_movie_genres_movie" FOREIGN KEY ("movie_id") REFERENCES "movies"("id"),
+    CONSTRAINT "fk_movie_genres_genre" FOREIGN KEY ("genre_id") REFERENCES "genres"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "people" (
+    "id" bigserial,
+    "name" varchar(100) NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_people_deleted_at" ON "people" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_people_name" ON "people" ("name");
+
+CREATE TABLE IF NOT EXISTS "credits" (
+    "id" bigserial,
+    "movie_id" bigint NOT NULL,
+    "person_id" bigint NOT NULL,
+    "role" varchar(20) NOT NULL,
+    "character" varchar(100),
+    "billing_order" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_credits_person" FOREIGN KEY ("person_id") REFERENCES "people"("id"),
+    CONSTRAINT "fk_movies_credits" FOREIGN KEY ("movie_id") REFERENCES "movies"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_credits_person_id" ON "credits" ("person_id");
+CREATE INDEX IF NOT EXISTS "idx_credits_movie_id" ON "credits" ("movie_id");
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" bigserial,
+    "username" text NOT NULL,
+    "password" text NOT NULL,
+    "role" varchar(20) NOT NULL DEFAULT 'viewer',
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_users_username" UNIQUE ("username")
+);
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "role" varchar(20) NOT NULL DEFAULT 'viewer';
+
+CREATE TABLE IF NOT EXISTS "reviews" (
+    "id" bigserial,
+    "movie_id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "score" bigint NOT NULL,
+    "text" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_reviews_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_reviews_movie_user" ON "reviews" ("movie_id","user_id");
+
+CREATE TABLE IF NOT EXISTS "watchlist_items" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "movie_id" bigint NOT NULL,
+    "position" bigint NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_watchlist_items_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_watchlist_items_movie" FOREIGN KEY ("movie_id") REFERENCES "movies"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_watchlist_user_movie" ON "watchlist_items" ("user_id","movie_id");
+
+CREATE TABLE IF NOT EXISTS "watched_entries" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "movie_id" bigint NOT NULL,
+    "watched_on" date NOT NULL,
+    "rewatch" boolean NOT NULL DEFAULT false,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_watched_entries_movie" FOREIGN KEY ("movie_id") REFERENCES "movies"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_watched_entries_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_watched_entries_movie_id" ON "watched_entries" ("movie_id");
+CREATE INDEX IF NOT EXISTS "idx_watched_entries_user_id" ON "watched_entries" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "refresh_tokens" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "family_id" varchar(64) NOT NULL,
+    "token_hash" varchar(64) NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_refresh_tokens_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_revoked_at" ON "refresh_tokens" ("revoked_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_refresh_tokens_token_hash" ON "refresh_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_family_id" ON "refresh_tokens" ("family_id");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_user_id" ON "refresh_tokens" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "movie_revisions" (
+    "id" bigserial,
+    "movie_id" bigint NOT NULL,
+    "action" varchar(20) NOT NULL,
+    "user_id" bigint,
+    "changes" jsonb NOT NULL,
+    "snapshot" jsonb NOT NULL,
+    "reverted_from" bigint,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_movie_revisions_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_movie_revisions_movie_id" ON "movie_revisions" ("movie_id");
+CREATE INDEX IF NOT EXISTS "idx_movie_revisions_user_id" ON "movie_revisions" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "media_files" (
+    "id" bigserial,
+    "movie_id" bigint NOT NULL,
+    "storage_key" varchar(255) NOT NULL,
+    "filename" varchar(255),
+    "content_type" varchar(100) NOT NULL,
+    "size" bigint NOT NULL,
+    "sha256" varchar(64) NOT NULL,
+    "hls_status" varchar(20),
+    "hls_error" varchar(500),
+    "hls_segments" bigint,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_movies_media" FOREIGN KEY ("movie_id") REFERENCES "movies"("id")
+);
+ALTER TABLE "media_files" ADD COLUMN IF NOT EXISTS "hls_status" varchar(20);
+ALTER TABLE "media_files" ADD COLUMN IF NOT EXISTS "hls_error" varchar(500);
+ALTER TABLE "media_files" ADD COLUMN IF NOT EXISTS "hls_segments" bigint;
+CREATE INDEX IF NOT EXISTS "idx_media_files_hls_status" ON "media_files" ("hls_status");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_media_movie_sha256" ON "media_files" ("movie_id","sha256");
+
+CREATE TABLE IF NOT EXISTS "upload_sessions" (
+    "id" varchar(64),
+    "movie_id" bigint NOT NULL,
+    "filename" varchar(255),
+    "size" bigint NOT NULL,
+    "offset" bigint NOT NULL DEFAULT 0,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_upload_sessions_movie_id" ON "upload_sessions" ("movie_id");
+CREATE INDEX IF NOT EXISTS "idx_upload_sessions_expires_at" ON "upload_sessions" ("expires_at");
+
+CREATE TABLE IF NOT EXISTS "movie_images" (
+    "id" bigserial,
+    "movie_id" bigint NOT NULL,
+    "kind" varchar(20) NOT NULL,
+    "storage_key" varchar(255) NOT NULL,
+    "format" varchar(10) NOT NULL,
+    "width" bigint NOT NULL,
+    "height" bigint NOT NULL,
+    "thumbnails" varchar(100),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_movies_images" FOREIGN KEY ("movie_id") REFERENCES "movies"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_movie_images_movie_id" ON "movie_images" ("movie_id");
+
+CREATE TABLE IF NOT EXISTS "subtitle_tracks" (
+    "id" bigserial,
+    "movie_id" bigint NOT NULL,
+    "language" varchar(35) NOT NULL,
+    "label" varchar(100),
+    "format" varchar(10) NOT NULL,
+    "storage_key" varchar(255) NOT NULL,
+    "cue_count" bigint NOT NULL,
+    "offset_ms" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_movies_subtitles" FOREIGN KEY ("movie_id") REFERENCES "movies"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_subtitle_movie_language" ON "subtitle_tracks" ("movie_id","language");
@@ -0,0 +1,5 @@
+-- pg_trgm is left installed, other database objects may use it.
+DROP INDEX IF EXISTS idx_movies_director_trgm;
+DROP INDEX IF EXISTS idx_movies_title_trgm;
+DROP INDEX IF EXISTS idx_movies_search_vector;
+ALTER TABLE movies DROP COLUMN IF EXISTS search_vector;
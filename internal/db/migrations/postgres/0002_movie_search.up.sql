@@ -0,0 +1,14 @@
+-- The weighted tsvector column and the indexes used by
+-- services.PostgresMovieSearcher.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(director, '')), 'B') ||
+        setweight(to_tsvector('english', COALESCE(plot, '')), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_movies_search_vector ON movies USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_movies_title_trgm ON movies USING GIN (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_movies_director_trgm ON movies USING GIN (director gin_trgm_ops);
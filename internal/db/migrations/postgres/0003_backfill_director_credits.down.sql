@@ -0,0 +1,8 @@
+-- The credits made by the backfill cannot be told apart from ones added
+-- since, nor can the director column be restored, so reverting refuses
+-- rather than leave the schema_migrations row out of step with the data.
+DO $$
+BEGIN
+    RAISE EXCEPTION 'migration 0003_backfill_director_credits cannot be reverted; restore a backup taken before it instead';
+END
+$$;
@@ -0,0 +1,28 @@
+-- Turns the free-text director of every movie that has no director credit
+-- yet into people and director credits. Names are split on "," and "&" and
+-- matched to existing people ignoring case, and the director column is then
+-- rewritten from the credits so that differently cased spellings converge.
+INSERT INTO people (name, created_at, updated_at)
+SELECT DISTINCT ON (LOWER(TRIM(d.name))) TRIM(d.name), NOW(), NOW()
+FROM movies m, regexp_split_to_table(m.director, '[,&]') AS d(name)
+WHERE TRIM(d.name) <> ''
+    AND NOT EXISTS (SELECT 1 FROM credits c WHERE c.movie_id = m.id AND c.role = 'director')
+    AND NOT EXISTS (SELECT 1 FROM people p WHERE LOWER(p.name) = LOWER(TRIM(d.name)))
+ORDER BY LOWER(TRIM(d.name)), m.id;
+
+INSERT INTO credits (movie_id, person_id, role, billing_order, created_at, updated_at)
+SELECT m.id,
+    (SELECT p.id FROM people p WHERE LOWER(p.name) = LOWER(TRIM(d.name)) ORDER BY p.id LIMIT 1),
+    'director', d.ord - 1, NOW(), NOW()
+FROM movies m, regexp_split_to_table(m.director, '[,&]') WITH ORDINALITY AS d(name, ord)
+WHERE TRIM(d.name) <> ''
+    AND NOT EXISTS (SELECT 1 FROM credits c WHERE c.movie_id = m.id AND c.role = 'director');
+
+UPDATE movies m SET director = LEFT(d.names, 100)
+FROM (
+    SELECT c.movie_id, string_agg(p.name, ', ' ORDER BY c.billing_order, c.id) AS names
+    FROM credits c JOIN people p ON p.id = c.person_id
+    WHERE c.role = 'director'
+    GROUP BY c.movie_id
+) d
+WHERE d.movie_id = m.id AND m.director IS DISTINCT FROM LEFT(d.names, 100);
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS `subtitle_tracks`;
+DROP TABLE IF EXISTS `movie_images`;
+DROP TABLE IF EXISTS `upload_sessions`;
+DROP TABLE IF EXISTS `media_files`;
+DROP TABLE IF EXISTS `movie_revisions`;
+DROP TABLE IF EXISTS `refresh_tokens`;
+DROP TABLE IF EXISTS `watched_entries`;
+DROP TABLE IF EXISTS `watchlist_items`;
+DROP TABLE IF EXISTS `reviews`;
+DROP TABLE IF EXISTS `users`;
+DROP TABLE IF EXISTS `credits`;
+DROP TABLE IF EXISTS `people`;
+DROP TABLE IF EXISTS `movie_genres`;
+DROP TABLE IF EXISTS `movies`;
+DROP TABLE IF EXISTS `genres`;
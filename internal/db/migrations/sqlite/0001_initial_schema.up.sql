@@ -0,0 +1,197 @@
+-- The schema as AutoMigrate created it before migrations were introduced.
+-- Every statement is skipped when its table or index already exists, so
+-- databases created by AutoMigrate are taken over as they are.
+
+CREATE TABLE IF NOT EXISTS `genres` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `name` text NOT NULL,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `uni_genres_name` UNIQUE (`name`)
+);
+
+CREATE TABLE IF NOT EXISTS `movies` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `title` text NOT NULL,
+    `director` text,
+    `year` integer NOT NULL,
+    `plot` text,
+    `rating` real NOT NULL DEFAULT 0,
+    `votes` integer NOT NULL DEFAULT 0,
+    `version` integer NOT NULL DEFAULT 1,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_movies_deleted_at` ON `movies`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `movie_genres` (
+    `movie_id` integer,
+    `genre_id` integer,
+    PRIMARY KEY (`movie_id`,`genre_id`),
+    CONSTRAINT `fk_movie_genres_movie` FOREIGN KEY (`movie_id`) REFERENCES `movies`(`id`),
+    CONSTRAINT `fk_movie_genres_genre` FOREIGN KEY (`genre_id`) REFERENCES `genres`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `people` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `name` text NOT NULL,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_people_deleted_at` ON `people`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_people_name` ON `people`(`name`);
+
+CREATE TABLE IF NOT EXISTS `credits` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `movie_id` integer NOT NULL,
+    `person_id` integer NOT NULL,
+    `role` text NOT NULL,
+    `character` text,
+    `billing_order` integer NOT NULL DEFAULT 0,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_movies_credits` FOREIGN KEY (`movie_id`) REFERENCES `movies`(`id`),
+    CONSTRAINT `fk_credits_person` FOREIGN KEY (`person_id`) REFERENCES `people`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_credits_person_id` ON `credits`(`person_id`);
+CREATE INDEX IF NOT EXISTS `idx_credits_movie_id` ON `credits`(`movie_id`);
+
+CREATE TABLE IF NOT EXISTS `users` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `username` text NOT NULL,
+    `password` text NOT NULL,
+    `role` text NOT NULL DEFAULT 'viewer',
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    CONSTRAINT `uni_users_username` UNIQUE (`username`)
+);
+CREATE INDEX IF NOT EXISTS `idx_users_deleted_at` ON `users`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `reviews` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `movie_id` integer NOT NULL,
+    `user_id` integer NOT NULL,
+    `score` integer NOT NULL,
+    `text` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_reviews_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_reviews_movie_user` ON `reviews`(`movie_id`,`user_id`);
+
+CREATE TABLE IF NOT EXISTS `watchlist_items` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `movie_id` integer NOT NULL,
+    `position` integer NOT NULL,
+    `created_at` datetime,
+    CONSTRAINT `fk_watchlist_items_movie` FOREIGN KEY (`movie_id`) REFERENCES `movies`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_watchlist_items_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_watchlist_user_movie` ON `watchlist_items`(`user_id`,`movie_id`);
+
+CREATE TABLE IF NOT EXISTS `watched_entries` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `movie_id` integer NOT NULL,
+    `watched_on` date NOT NULL,
+    `rewatch` numeric NOT NULL DEFAULT false,
+    `created_at` datetime,
+    CONSTRAINT `fk_watched_entries_movie` FOREIGN KEY (`movie_id`) REFERENCES `movies`(`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk_watched_entries_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS `idx_watched_entries_movie_id` ON `watched_entries`(`movie_id`);
+CREATE INDEX IF NOT EXISTS `idx_watched_entries_user_id` ON `watched_entries`(`user_id`);
+
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `family_id` text NOT NULL,
+    `token_hash` text NOT NULL,
+    `expires_at` datetime NOT NULL,
+    `revoked_at` datetime,
+    `created_at` datetime,
+    CONSTRAINT `fk_refresh_tokens_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_revoked_at` ON `refresh_tokens`(`revoked_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_refresh_tokens_token_hash` ON `refresh_tokens`(`token_hash`);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_family_id` ON `refresh_tokens`(`family_id`);
+CREATE INDEX IF NOT EXISTS `idx_refresh_tokens_user_id` ON `refresh_tokens`(`user_id`);
+
+CREATE TABLE IF NOT EXISTS `movie_revisions` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `movie_id` integer NOT NULL,
+    `action` text NOT NULL,
+    `user_id` integer,
+    `changes` jsonb NOT NULL,
+    `snapshot` jsonb NOT NULL,
+    `reverted_from` integer,
+    `created_at` datetime,
+    CONSTRAINT `fk_movie_revisions_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS `idx_movie_revisions_user_id` ON `movie_revisions`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_movie_revisions_movie_id` ON `movie_revisions`(`movie_id`);
+
+CREATE TABLE IF NOT EXISTS `media_files` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `movie_id` integer NOT NULL,
+    `storage_key` text NOT NULL,
+    `filename` text,
+    `content_type` text NOT NULL,
+    `size` integer NOT NULL,
+    `sha256` text NOT NULL,
+    `hls_status` text,
+    `hls_error` text,
+    `hls_segments` integer,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_movies_media` FOREIGN KEY (`movie_id`) REFERENCES `movies`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_media_files_hls_status` ON `media_files`(`hls_status`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_media_movie_sha256` ON `media_files`(`movie_id`,`sha256`);
+
+CREATE TABLE IF NOT EXISTS `upload_sessions` (
+    `id` text,
+    `movie_id` integer NOT NULL,
+    `filename` text,
+    `size` integer NOT NULL,
+    `offset` integer NOT NULL DEFAULT 0,
+    `expires_at` datetime NOT NULL,
+    `created_at` datetime,
+    `updated_at` datetime,
+    PRIMARY KEY (`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_upload_sessions_movie_id` ON `upload_sessions`(`movie_id`);
+CREATE INDEX IF NOT EXISTS `idx_upload_sessions_expires_at` ON `upload_sessions`(`expires_at`);
+
+CREATE TABLE IF NOT EXISTS `movie_images` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `movie_id` integer NOT NULL,
+    `kind` text NOT NULL,
+    `storage_key` text NOT NULL,
+    `format` text NOT NULL,
+    `width` integer NOT NULL,
+    `height` integer NOT NULL,
+    `thumbnails` text,
+    `created_at` datetime,
+    CONSTRAINT `fk_movies_images` FOREIGN KEY (`movie_id`) REFERENCES `movies`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_movie_images_movie_id` ON `movie_images`(`movie_id`);
+
+CREATE TABLE IF NOT EXISTS `subtitle_tracks` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `movie_id` integer NOT NULL,
+    `language` text NOT NULL,
+    `label` text,
+    `format` text NOT NULL,
+    `storage_key` text NOT NULL,
+    `cue_count` integer NOT NULL,
+    `offset_ms` integer NOT NULL DEFAULT 0,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_movies_subtitles` FOREIGN KEY (`movie_id`) REFERENCES `movies`(`id`)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_subtitle_movie_language` ON `subtitle_tracks`(`movie_id`,`language`);
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+type Genre struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Name      string    `json:"name" gorm:"size:50;unique;not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type GenreRequest struct {
+	Name string `json:"name" binding:"required,max=50"`
+}
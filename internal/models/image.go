@@ -0,0 +1,80 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of movie images. A movie has at most one image of each kind.
+const (
+	ImageKindPoster   = "poster"
+	ImageKindBackdrop = "backdrop"
+)
+
+// MovieURLPrefix is where movies and the files attached to them are served,
+// as registered by the router.
+const MovieURLPrefix = "/api/v1/movies"
+
+// MovieImage is a poster or backdrop of a movie. The original and its
+// thumbnails are stored under StorageKey as original<ext> and w<width><ext>.
+type MovieImage struct {
+	ID         uint   `json:"id" gorm:"primarykey"`
+	MovieID    uint   `json:"movie_id" gorm:"not null;index"`
+	Kind       string `json:"kind" gorm:"size:20;not null"`
+	StorageKey string `json:"-" gorm:"size:255;not null"`
+	Format     string `json:"-" gorm:"size:10;not null"`
+	Width      int    `json:"width" gorm:"not null"`
+	Height     int    `json:"height" gorm:"not null"`
+	// Thumbnails lists the widths of the thumbnails, comma separated.
+	Thumbnails string `json:"-" gorm:"size:100"`
+	// URLs maps "original" and "w<width>" to where each size is served.
+	URLs      map[string]string `json:"urls" gorm:"-"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Sizes lists the names of the stored sizes: "original" followed by one
+// "w<width>" per thumbnail.
+func (i *MovieImage) Sizes() []string {
+	sizes := []string{"original"}
+	for _, width := range strings.Split(i.Thumbnails, ",") {
+		if _, err := strconv.Atoi(width); err == nil {
+			sizes = append(sizes, "w"+width)
+		}
+	}
+	return sizes
+}
+
+func (i *MovieImage) AfterFind(tx *gorm.DB) error {
+	i.setURLs()
+	return nil
+}
+
+func (i *MovieImage) AfterCreate(tx *gorm.DB) error {
+	i.setURLs()
+	return nil
+}
+
+func (i *MovieImage) setURLs() {
+	i.URLs = make(map[string]string)
+	for _, size := range i.Sizes() {
+		i.URLs[size] = fmt.Sprintf("%s/%d/images/%d/%s", MovieURLPrefix, i.MovieID, i.ID, size)
+	}
+}
+
+// AfterFind exposes the preloaded images of the movie as its Poster and
+// Backdrop.
+func (m *Movie) AfterFind(tx *gorm.DB) error {
+	for idx := range m.Images {
+		switch image := &m.Images[idx]; image.Kind {
+		case ImageKindPoster:
+			m.Poster = image
+		case ImageKindBackdrop:
+			m.Backdrop = image
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+)
+
+// States of the HLS packaging job of a media file.
+const (
+	HLSStatusQueued     = "queued"
+	HLSStatusProcessing = "processing"
+	HLSStatusReady      = "ready"
+	HLSStatusFailed     = "failed"
+)
+
+// MediaFile is a video uploaded for a movie. StorageKey locates the file in
+// the blob store; it is named after the SHA-256 of the content, so the same
+// file uploaded twice for a movie is stored once.
+//
+// MP4 files are also packaged for HLS in the background. HLSStatus tracks
+// that job and is empty for files that are not packaged.
+type MediaFile struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	MovieID     uint      `json:"movie_id" gorm:"not null;uniqueIndex:idx_media_movie_sha256"`
+	StorageKey  string    `json:"-" gorm:"size:255;not null"`
+	Filename    string    `json:"filename" gorm:"size:255"`
+	ContentType string    `json:"content_type" gorm:"size:100;not null"`
+	Size        int64     `json:"size" gorm:"not null"`
+	SHA256      string    `json:"sha256" gorm:"size:64;not null;uniqueIndex:idx_media_movie_sha256"`
+	HLSStatus   string    `json:"hls_status,omitempty" gorm:"size:20;index"`
+	HLSError    string    `json:"hls_error,omitempty" gorm:"size:500"`
+	HLSSegments int       `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// UploadSession tracks a resumable upload. The client sends the file in
+// chunks, each starting at Offset, until Offset reaches Size.
+type UploadSession struct {
+	ID        string    `json:"id" gorm:"primarykey;size:64"`
+	MovieID   uint      `json:"movie_id" gorm:"not null;index"`
+	Filename  string    `json:"filename" gorm:"size:255"`
+	Size      int64     `json:"size" gorm:"not null"`
+	Offset    int64     `json:"offset" gorm:"not null;default:0"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type UploadSessionRequest struct {
+	Filename string `json:"filename" binding:"max=255"`
+	Size     int64  `json:"size" binding:"required,min=1"`
+}
+
+// UploadChunkResponse is returned for every chunk of a resumable upload.
+// Media is set once the last chunk has been received.
+type UploadChunkResponse struct {
+	Session *UploadSession `json:"session"`
+	Media   *MediaFile     `json:"media,omitempty"`
+}
+
+// StreamURLResponse is a signed URL that streams a media file without an
+// Authorization header until ExpiresAt. HLSURL is set once the HLS playlist
+// is ready.
+type StreamURLResponse struct {
+	URL       string    `json:"url"`
+	HLSURL    string    `json:"hls_url,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
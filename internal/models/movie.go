@@ -6,15 +6,30 @@ import (
     "gorm.io/gorm"
 )
 
+// Movie is a catalog entry. Director lists the names of the movie's director
+// credits and is kept in sync by the services for searching and sorting;
+// Credits is the source of truth. Rating and Votes summarise the movie's
+// reviews and are recomputed whenever a review changes. Version goes up with
+// every edit of the other fields and is what a movie's ETag is made from.
 type Movie struct {
-    ID        uint           `json:"id" gorm:"primarykey"`
-    Title     string         `json:"title" gorm:"size:100;not null"`
-    Director  string         `json:"director" gorm:"size:100"`
-    Year      int            `json:"year" gorm:"not null"`
-    Plot      string         `json:"plot" gorm:"type:text"`
-    CreatedAt time.Time      `json:"created_at"`
-    UpdatedAt time.Time      `json:"updated_at"`
-    DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+    ID        uint            `json:"id" gorm:"primarykey"`
+    Title     string          `json:"title" gorm:"size:100;not null"`
+    Director  string          `json:"director" gorm:"size:100"`
+    Year      int             `json:"year" gorm:"not null"`
+    Plot      string          `json:"plot" gorm:"type:text"`
+    Rating    float64         `json:"average_rating" gorm:"not null;default:0"`
+    Votes     int             `json:"vote_count" gorm:"not null;default:0"`
+    Version   int             `json:"version" gorm:"not null;default:1"`
+    Genres    []Genre         `json:"genres,omitempty" gorm:"many2many:movie_genres;"`
+    Credits   []Credit        `json:"credits,omitempty"`
+    Media     []MediaFile     `json:"media,omitempty"`
+    Images    []MovieImage    `json:"-"`
+    Poster    *MovieImage     `json:"poster,omitempty" gorm:"-"`
+    Backdrop  *MovieImage     `json:"backdrop,omitempty" gorm:"-"`
+    Subtitles []SubtitleTrack `json:"subtitles,omitempty"`
+    CreatedAt time.Time       `json:"created_at"`
+    UpdatedAt time.Time       `json:"updated_at"`
+    DeletedAt gorm.DeletedAt  `json:"-" gorm:"index"`
 }
 
 type CreateMovieRequest struct {
@@ -22,6 +37,7 @@ type CreateMovieRequest struct {
     Director string `json:"director" binding:"required"`
     Year     int    `json:"year" binding:"required,min=1800,max=2100"`
     Plot     string `json:"plot"`
+    GenreIDs []uint `json:"genre_ids"`
 }
 
 type UpdateMovieRequest struct {
@@ -29,4 +45,51 @@ type UpdateMovieRequest struct {
     Director string `json:"director"`
     Year     int    `json:"year" binding:"omitempty,min=1800,max=2100"`
     Plot     string `json:"plot"`
-}
\ No newline at end of file
+    // GenreIDs replaces the movie's genres when present; an empty list
+    // removes them all.
+    GenreIDs []uint `json:"genre_ids"`
+}
+
+type MovieListQuery struct {
+    Page     int    `form:"page" binding:"omitempty,min=1"`
+    Limit    int    `form:"limit" binding:"omitempty,min=1,max=100"`
+    Title    string `form:"title"`
+    Director string `form:"director"`
+    YearFrom int    `form:"year_from" binding:"omitempty,min=1800,max=2100"`
+    YearTo   int    `form:"year_to" binding:"omitempty,min=1800,max=2100"`
+    GenreIDs []uint `form:"genre"`
+    Sort     string `form:"sort"`
+}
+
+type MovieListResponse struct {
+    Data  []Movie `json:"data"`
+    Total int64   `json:"total"`
+    Page  int     `json:"page"`
+    Limit int     `json:"limit"`
+    Next  string  `json:"next,omitempty"`
+    Prev  string  `json:"prev,omitempty"`
+}
+
+type MovieSearchQuery struct {
+    Q     string `form:"q" binding:"required"`
+    Page  int    `form:"page" binding:"omitempty,min=1"`
+    Limit int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type MovieSearchHighlight struct {
+    Title    string `json:"title"`
+    Director string `json:"director"`
+    Plot     string `json:"plot"`
+}
+
+type MovieSearchResult struct {
+    Movie
+    Rank      float64              `json:"rank"`
+    Highlight MovieSearchHighlight `json:"highlight"`
+}
+
+type MovieSearchResponse struct {
+    Data  []MovieSearchResult `json:"data"`
+    Page  int                 `json:"page"`
+    Limit int                 `json:"limit"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// MovieExportQuery holds the options of an export request. The movies are
+// filtered like a listing, see MovieListQuery.
+type MovieExportQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv ndjson json"`
+	// Gzip compresses the export into a .gz file.
+	Gzip bool `form:"gzip"`
+}
+
+// MovieExportRow is a movie as written by an export. Its fields are a
+// superset of those read by an import, so an export can be imported again.
+type MovieExportRow struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Director  string    `json:"director"`
+	Year      int       `json:"year"`
+	Plot      string    `json:"plot"`
+	GenreIDs  []uint    `json:"genre_ids"`
+	Genres    []string  `json:"genres"`
+	Rating    float64   `json:"average_rating"`
+	Votes     int       `json:"vote_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
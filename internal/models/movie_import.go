@@ -0,0 +1,39 @@
+package models
+
+// Formats of movie imports and exports. JSON, a single array, can only be
+// exported.
+const (
+	CatalogFormatCSV    = "csv"
+	CatalogFormatNDJSON = "ndjson"
+	CatalogFormatJSON   = "json"
+)
+
+// MovieImportQuery holds the options of an import request. The format is
+// taken from the Content-Type header when it is not given.
+type MovieImportQuery struct {
+	Format    string `form:"format" binding:"omitempty,oneof=csv ndjson"`
+	DryRun    bool   `form:"dry_run"`
+	BatchSize int    `form:"batch_size" binding:"omitempty,min=1,max=5000"`
+}
+
+// MovieImportReport summarizes an import. Rows that fail validation are
+// skipped and listed in Errors; the other rows are still imported.
+type MovieImportReport struct {
+	DryRun  bool `json:"dry_run"`
+	Rows    int  `json:"rows"`
+	Created int  `json:"created"`
+	Updated int  `json:"updated"`
+	Failed  int  `json:"failed"`
+	// Errors lists the first failed rows; ErrorsTruncated is set when
+	// there were more.
+	Errors          []MovieImportError `json:"errors"`
+	ErrorsTruncated bool               `json:"errors_truncated,omitempty"`
+}
+
+// MovieImportError is a problem with one row of an import. Line is the line
+// of the row in the uploaded file, counting the CSV header.
+type MovieImportError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
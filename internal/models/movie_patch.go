@@ -0,0 +1,18 @@
+package models
+
+// Media types of the patch documents accepted by PATCH /movies/:id.
+const (
+	PatchTypeMerge = "application/merge-patch+json"
+	PatchTypeJSON  = "application/json-patch+json"
+)
+
+// MoviePatchDocument is the document a movie patch is applied to: the
+// editable fields of the movie. Unlike UpdateMovieRequest, every field is
+// written, so a patch can clear the director, plot or genres.
+type MoviePatchDocument struct {
+	Title    string `json:"title" binding:"required,max=100"`
+	Director string `json:"director" binding:"max=100"`
+	Year     int    `json:"year" binding:"required,min=1800,max=2100"`
+	Plot     string `json:"plot"`
+	GenreIDs []uint `json:"genre_ids"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Actions recorded by a MovieRevision.
+const (
+	RevisionActionCreate  = "create"
+	RevisionActionUpdate  = "update"
+	RevisionActionDelete  = "delete"
+	RevisionActionRevert  = "revert"
+	RevisionActionRestore = "restore"
+)
+
+// MovieSnapshot holds the editable fields of a movie at one point in time.
+type MovieSnapshot struct {
+	Title    string `json:"title"`
+	Director string `json:"director"`
+	Year     int    `json:"year"`
+	Plot     string `json:"plot"`
+	GenreIDs []uint `json:"genre_ids"`
+}
+
+// FieldChange is the value of a field before and after a revision. From is
+// null when the movie was created and To when it was deleted.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// MovieRevision records one change to a movie: who made it, which fields
+// changed, and the movie as it was afterwards (or, for a delete, when it was
+// deleted), which is what reverting to the revision restores.
+type MovieRevision struct {
+	ID      uint   `json:"id" gorm:"primarykey"`
+	MovieID uint   `json:"movie_id" gorm:"not null;index"`
+	Action  string `json:"action" gorm:"size:20;not null"`
+	// UserID is the user who made the change. It is empty for changes made
+	// from the command line.
+	UserID       *uint                  `json:"user_id" gorm:"index"`
+	User         *User                  `json:"user,omitempty" gorm:"constraint:OnDelete:SET NULL"`
+	Changes      map[string]FieldChange `json:"changes" gorm:"type:jsonb;serializer:json;not null"`
+	Snapshot     MovieSnapshot          `json:"snapshot" gorm:"type:jsonb;serializer:json;not null"`
+	RevertedFrom *uint                  `json:"reverted_from,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CreditRoleDirector = "director"
+	CreditRoleActor    = "actor"
+	CreditRoleWriter   = "writer"
+)
+
+type Person struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	Name      string         `json:"name" gorm:"size:100;not null;index"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// Credit links a person to a movie in a given role. Character is only set
+// for actors, and BillingOrder ranks credits of the same role.
+type Credit struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	MovieID      uint      `json:"movie_id" gorm:"not null;index"`
+	PersonID     uint      `json:"person_id" gorm:"not null;index"`
+	Role         string    `json:"role" gorm:"size:20;not null"`
+	Character    string    `json:"character,omitempty" gorm:"size:100"`
+	BillingOrder int       `json:"billing_order" gorm:"not null;default:0"`
+	Person       *Person   `json:"person,omitempty"`
+	Movie        *Movie    `json:"movie,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type PersonRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type PersonListQuery struct {
+	Page  int    `form:"page" binding:"omitempty,min=1"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Name  string `form:"name"`
+}
+
+type PersonListResponse struct {
+	Data  []Person `json:"data"`
+	Total int64    `json:"total"`
+	Page  int      `json:"page"`
+	Limit int      `json:"limit"`
+	Next  string   `json:"next,omitempty"`
+	Prev  string   `json:"prev,omitempty"`
+}
+
+type CreditRequest struct {
+	PersonID     uint   `json:"person_id" binding:"required"`
+	Role         string `json:"role" binding:"required,oneof=director actor writer"`
+	Character    string `json:"character" binding:"max=100"`
+	BillingOrder int    `json:"billing_order" binding:"min=0"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken is a server-side record of an issued refresh token. Only the
+// SHA-256 hash of the token is stored. Every token obtained by rotating a
+// token shares its FamilyID, so that a whole login session can be revoked.
+type RefreshToken struct {
+	ID        uint       `gorm:"primarykey"`
+	UserID    uint       `gorm:"not null;index"`
+	FamilyID  string     `gorm:"size:64;not null;index"`
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	RevokedAt *time.Time `gorm:"index"`
+	User      *User      `gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// Review is a user's score for a movie. A user can review each movie once.
+type Review struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	MovieID   uint      `json:"movie_id" gorm:"not null;uniqueIndex:idx_reviews_movie_user"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_reviews_movie_user"`
+	Score     int       `json:"score" gorm:"not null"`
+	Text      string    `json:"text,omitempty" gorm:"type:text"`
+	User      *User     `json:"user,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ReviewRequest struct {
+	Score int    `json:"score" binding:"required,min=1,max=10"`
+	Text  string `json:"text" binding:"max=5000"`
+}
+
+type ReviewListQuery struct {
+	Page  int `form:"page" binding:"omitempty,min=1"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type ReviewListResponse struct {
+	Data  []Review `json:"data"`
+	Total int64    `json:"total"`
+	Page  int      `json:"page"`
+	Limit int      `json:"limit"`
+	Next  string   `json:"next,omitempty"`
+	Prev  string   `json:"prev,omitempty"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SubtitleTrack is the subtitles of a movie in one language. The uploaded
+// SRT or WebVTT file is stored as is and served as WebVTT, shifted by
+// OffsetMs.
+type SubtitleTrack struct {
+	ID         uint   `json:"id" gorm:"primarykey"`
+	MovieID    uint   `json:"movie_id" gorm:"not null;uniqueIndex:idx_subtitle_movie_language"`
+	Language   string `json:"language" gorm:"size:35;not null;uniqueIndex:idx_subtitle_movie_language"`
+	Label      string `json:"label" gorm:"size:100"`
+	Format     string `json:"format" gorm:"size:10;not null"`
+	StorageKey string `json:"-" gorm:"size:255;not null"`
+	CueCount   int    `json:"cue_count" gorm:"not null"`
+	// OffsetMs shifts every cue, in milliseconds, to line the subtitles up
+	// with the video.
+	OffsetMs  int64     `json:"offset_ms" gorm:"not null;default:0"`
+	URL       string    `json:"url" gorm:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (t *SubtitleTrack) AfterFind(tx *gorm.DB) error {
+	t.setURL()
+	return nil
+}
+
+func (t *SubtitleTrack) AfterSave(tx *gorm.DB) error {
+	t.setURL()
+	return nil
+}
+
+func (t *SubtitleTrack) setURL() {
+	t.URL = fmt.Sprintf("%s/%d/subtitles/%s", MovieURLPrefix, t.MovieID, t.Language)
+}
+
+// SubtitleUploadRequest holds the form fields sent with a subtitle file.
+type SubtitleUploadRequest struct {
+	Label    string `form:"label" binding:"max=100"`
+	OffsetMs int64  `form:"offset_ms"`
+}
+
+type UpdateSubtitleRequest struct {
+	Label    *string `json:"label" binding:"omitempty,max=100"`
+	OffsetMs *int64  `json:"offset_ms"`
+}
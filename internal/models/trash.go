@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// DeletedMovie is a movie in the trash with the time it was deleted.
+type DeletedMovie struct {
+	Movie
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type DeletedMovieListQuery struct {
+	Page  int `form:"page" binding:"omitempty,min=1"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type DeletedMovieListResponse struct {
+	Data  []DeletedMovie `json:"data"`
+	Total int64          `json:"total"`
+	Page  int            `json:"page"`
+	Limit int            `json:"limit"`
+}
+
+// TrashRequest names the deleted movies to restore or purge.
+type TrashRequest struct {
+	IDs []uint `json:"ids" binding:"required,min=1,max=1000"`
+}
+
+// TrashResponse lists the movies that were restored or purged, and the
+// requested IDs that are not in the trash.
+type TrashResponse struct {
+	IDs      []uint `json:"ids"`
+	NotFound []uint `json:"not_found"`
+}
@@ -6,10 +6,17 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
 type User struct {
 	ID        uint           `json:"id" gorm:"primarykey"`
 	Username  string         `json:"username" gorm:"unique;not null"`
 	Password  string         `json:"-" gorm:"not null"`
+	Role      string         `json:"role" gorm:"size:20;not null;default:viewer"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -25,7 +32,16 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin editor viewer"`
+}
+
 type AuthResponse struct {
-	Token string `json:"token"`
-	User  *User  `json:"user"`
+	// Token is the access token to send as a Bearer token. It expires after
+	// ExpiresIn seconds, after which RefreshToken can be exchanged for a new
+	// pair on /auth/refresh.
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	User         *User  `json:"user"`
 }
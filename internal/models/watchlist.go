@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+// WatchlistItem is a movie a user wants to watch. Items are shown in
+// ascending Position, which the user can reorder.
+type WatchlistItem struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	UserID    uint      `json:"-" gorm:"not null;uniqueIndex:idx_watchlist_user_movie"`
+	MovieID   uint      `json:"movie_id" gorm:"not null;uniqueIndex:idx_watchlist_user_movie"`
+	Position  int       `json:"position" gorm:"not null"`
+	Movie     *Movie    `json:"movie,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	User      *User     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WatchedEntry records that a user watched a movie on a given day.
+type WatchedEntry struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	UserID    uint      `json:"-" gorm:"not null;index"`
+	MovieID   uint      `json:"movie_id" gorm:"not null;index"`
+	WatchedOn time.Time `json:"watched_on" gorm:"type:date;not null"`
+	Rewatch   bool      `json:"rewatch" gorm:"not null;default:false"`
+	Movie     *Movie    `json:"movie,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	User      *User     `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WatchlistRequest struct {
+	MovieID uint `json:"movie_id" binding:"required"`
+}
+
+type WatchlistOrderRequest struct {
+	MovieIDs []uint `json:"movie_ids" binding:"required"`
+}
+
+type WatchedEntryRequest struct {
+	MovieID uint `json:"movie_id" binding:"required"`
+	// WatchedOn is a date in the form 2006-01-02; it defaults to today.
+	WatchedOn string `json:"watched_on" binding:"omitempty,datetime=2006-01-02"`
+	Rewatch   bool   `json:"rewatch"`
+}
+
+type WatchedListQuery struct {
+	Page  int `form:"page" binding:"omitempty,min=1"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type WatchedListResponse struct {
+	Data  []WatchedEntry `json:"data"`
+	Total int64          `json:"total"`
+	Page  int            `json:"page"`
+	Limit int            `json:"limit"`
+	Next  string         `json:"next,omitempty"`
+	Prev  string         `json:"prev,omitempty"`
+}
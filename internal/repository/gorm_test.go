@@ -0,0 +1,71 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/mehmonov/movies-crud/config"
+	"github.com/mehmonov/movies-crud/internal/db"
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/repository"
+	"github.com/mehmonov/movies-crud/internal/repository/repotest"
+)
+
+// openTestDatabase returns a new in-memory SQLite database with the schema
+// of the migrations.
+func openTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := config.Default()
+	cfg.DB.Driver = config.DBDriverSQLite
+	cfg.DB.Path = ":memory:"
+	database, err := db.NewDatabase(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close(database) })
+	return database
+}
+
+func TestGormMovieRepository(t *testing.T) {
+	repotest.TestMovieRepository(t, func(t *testing.T) repository.MovieRepository {
+		return repository.NewGormMovieRepository(openTestDatabase(t))
+	})
+}
+
+func TestGormUserRepository(t *testing.T) {
+	repotest.TestUserRepository(t, func(t *testing.T) repository.UserRepository {
+		return repository.NewGormUserRepository(openTestDatabase(t))
+	})
+}
+
+func TestWithTx(t *testing.T) {
+	database := openTestDatabase(t)
+	repo := repository.NewGormMovieRepository(database)
+	failed := errors.New("failed")
+
+	var movie models.Movie
+	err := database.Transaction(func(tx *gorm.DB) error {
+		ctx := repository.WithTx(context.Background(), tx)
+		movie = models.Movie{Title: "Rolled back", Year: 2000}
+		if err := repo.Create(ctx, &movie); err != nil {
+			return err
+		}
+		if _, err := repo.Get(ctx, movie.ID); err != nil {
+			t.Errorf("Get inside the transaction: %v", err)
+		}
+		// Reading from a replica does not leave the transaction.
+		if _, err := repo.Get(repository.WithReplica(ctx), movie.ID); err != nil {
+			t.Errorf("Get from a replica inside the transaction: %v", err)
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("Transaction error = %v, want the error returned by fn", err)
+	}
+	if _, err := repo.Get(context.Background(), movie.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Get after rollback error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+// movieSortColumns maps the field names accepted in the sort query
+// parameter to their database columns.
+var movieSortColumns = map[string]string{
+	"id":             "id",
+	"title":          "title",
+	"director":       "director",
+	"year":           "year",
+	"average_rating": "rating",
+	"vote_count":     "votes",
+	"created_at":     "created_at",
+	"updated_at":     "updated_at",
+}
+
+// GormMovieRepository is a MovieRepository on a GORM database.
+type GormMovieRepository struct {
+	db *gorm.DB
+}
+
+func NewGormMovieRepository(db *gorm.DB) *GormMovieRepository {
+	return &GormMovieRepository{db: db}
+}
+
+func (r *GormMovieRepository) Get(ctx context.Context, id uint) (*models.Movie, error) {
+	var movie models.Movie
+	err := conn(ctx, r.db).
+		Preload("Genres").
+		Preload("Credits", func(db *gorm.DB) *gorm.DB {
+			return db.Order("role ASC, billing_order ASC, id ASC")
+		}).
+		Preload("Credits.Person").
+		Preload("Media", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Preload("Images").
+		Preload("Subtitles", func(db *gorm.DB) *gorm.DB {
+			return db.Order("language ASC")
+		}).
+		First(&movie, id).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &movie, nil
+}
+
+func (r *GormMovieRepository) List(ctx context.Context, query *models.MovieListQuery) ([]models.Movie, int64, error) {
+	order, err := movieOrder(query.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filtered := movieFilters(conn(ctx, r.db).Model(&models.Movie{}), query).Session(&gorm.Session{})
+
+	var total int64
+	if err := filtered.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	movies := []models.Movie{}
+	err = filtered.
+		Preload("Genres").
+		Preload("Images").
+		Order(order).
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&movies).Error
+	return movies, total, err
+}
+
+func (r *GormMovieRepository) Each(ctx context.Context, query *models.MovieListQuery, size int, fn func(movies []models.Movie) error) error {
+	var movies []models.Movie
+	return movieFilters(conn(ctx, r.db).Model(&models.Movie{}), query).
+		Preload("Genres", func(db *gorm.DB) *gorm.DB {
+			return db.Order("name ASC")
+		}).
+		FindInBatches(&movies, size, func(tx *gorm.DB, batch int) error {
+			return fn(movies)
+		}).Error
+}
+
+// movieFilters narrows db to the movies matching the filters in query.
+func movieFilters(db *gorm.DB, query *models.MovieListQuery) *gorm.DB {
+	if query.Title != "" {
+		db = db.Where(`LOWER(title) LIKE ? ESCAPE '\'`, ContainsPattern(query.Title))
+	}
+	if query.Director != "" {
+		db = db.Where(`LOWER(director) LIKE ? ESCAPE '\'`, ContainsPattern(query.Director))
+	}
+	if query.YearFrom != 0 {
+		db = db.Where("year >= ?", query.YearFrom)
+	}
+	if query.YearTo != 0 {
+		db = db.Where("year <= ?", query.YearTo)
+	}
+	if len(query.GenreIDs) > 0 {
+		genreMovies := db.Session(&gorm.Session{NewDB: true}).
+			Table("movie_genres").
+			Select("movie_id").
+			Where("genre_id IN ?", query.GenreIDs)
+		db = db.Where("movies.id IN (?)", genreMovies)
+	}
+	return db
+}
+
+// movieOrder turns a comma separated list of fields such as "-year,title"
+// into an ORDER BY clause. A leading "-" sorts that field descending.
+func movieOrder(sort string) (string, error) {
+	var clauses []string
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		direction := "ASC"
+		if strings.HasPrefix(field, "-") {
+			direction = "DESC"
+			field = field[1:]
+		}
+
+		column, ok := movieSortColumns[field]
+		if !ok {
+			return "", fmt.Errorf("%w: %q", ErrInvalidSort, field)
+		}
+		clauses = append(clauses, column+" "+direction)
+	}
+
+	// Always finish with the primary key so pages are stable.
+	clauses = append(clauses, "id ASC")
+	return strings.Join(clauses, ", "), nil
+}
+
+func (r *GormMovieRepository) GetForUpdate(ctx context.Context, id uint, version int) (*models.Movie, error) {
+	read := conn(ctx, r.db)
+	if version == 0 {
+		// Without a version the change applies to the movie as it is now,
+		// so concurrent changes are made one after another.
+		read = read.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var movie models.Movie
+	if err := read.First(&movie, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	if version != 0 && movie.Version != version {
+		return nil, ErrVersionMismatch
+	}
+	return &movie, nil
+}
+
+func (r *GormMovieRepository) Create(ctx context.Context, movie *models.Movie) error {
+	return conn(ctx, r.db).Create(movie).Error
+}
+
+func (r *GormMovieRepository) Update(ctx context.Context, movie *models.Movie) error {
+	result := conn(ctx, r.db).Model(movie).Where("version = ?", movie.Version).Updates(map[string]interface{}{
+		"title":   movie.Title,
+		"year":    movie.Year,
+		"plot":    movie.Plot,
+		"version": gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionMismatch
+	}
+	movie.Version++
+	return nil
+}
+
+func (r *GormMovieRepository) SetGenres(ctx context.Context, movieID uint, genres []models.Genre) error {
+	return conn(ctx, r.db).Model(&models.Movie{ID: movieID}).Association("Genres").Replace(genres)
+}
+
+func (r *GormMovieRepository) Delete(ctx context.Context, id uint, version int) error {
+	db := conn(ctx, r.db)
+	deleted := db.Where("id = ?", id)
+	if version != 0 {
+		deleted = deleted.Where("version = ?", version)
+	}
+	result := deleted.Delete(&models.Movie{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	var count int64
+	if err := db.Model(&models.Movie{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrVersionMismatch
+}
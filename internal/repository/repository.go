@@ -0,0 +1,144 @@
+// Package repository stores movies and users. The GORM implementations work
+// on PostgreSQL and on SQLite, which needs no server and can live in memory,
+// so that services can be run against either; see db.NewDatabase. Every
+// implementation must pass the contract suite in package repotest.
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+
+	"github.com/mehmonov/movies-crud/internal/db"
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+var (
+	ErrNotFound = errors.New("record not found")
+	// ErrVersionMismatch is returned by writes conditional on a movie
+	// version when the movie is at another version.
+	ErrVersionMismatch = errors.New("version mismatch")
+	// ErrDuplicate is returned when a record would take a unique value,
+	// such as a username, that is already in use.
+	ErrDuplicate = errors.New("duplicate record")
+	// ErrInvalidSort is returned by List when the query sorts by a field
+	// that movies cannot be sorted by.
+	ErrInvalidSort = errors.New("invalid sort field")
+)
+
+// MovieRepository stores movies and their genre links. Deleted movies are
+// kept in the trash and are not found by any of its methods.
+//
+// It has no transactions of its own: the credits, revisions and other rows
+// that change with a movie are written by services.MovieService on the
+// *gorm.DB, in a transaction that the GORM implementation joins through
+// WithTx.
+type MovieRepository interface {
+	// Get returns a movie with its genres, credits, media, images and
+	// subtitles.
+	Get(ctx context.Context, id uint) (*models.Movie, error)
+	// List returns a page of the movies matching the filters of query, in
+	// the order of query.Sort, with their genres and images, and how many
+	// movies match in total.
+	List(ctx context.Context, query *models.MovieListQuery) ([]models.Movie, int64, error)
+	// Each calls fn with every movie matching the filters of query, in
+	// batches of up to size movies ordered by ID, with their genres ordered
+	// by name. Paging and sorting in query are ignored.
+	Each(ctx context.Context, query *models.MovieListQuery, size int, fn func(movies []models.Movie) error) error
+
+	// GetForUpdate returns a movie, without associations, to be changed.
+	// Unless version is 0, the movie must be at that version. With version
+	// 0 the movie is locked until the transaction ends.
+	GetForUpdate(ctx context.Context, id uint, version int) (*models.Movie, error)
+	// Create adds movie, linked to movie.Genres.
+	Create(ctx context.Context, movie *models.Movie) error
+	// Update writes the title, year and plot of a movie read with
+	// GetForUpdate and moves it to the next version, unless it has been
+	// changed since it was read. Other columns, such as the rating, are left
+	// alone.
+	Update(ctx context.Context, movie *models.Movie) error
+	// SetGenres replaces the genres of a movie.
+	SetGenres(ctx context.Context, movieID uint, genres []models.Genre) error
+	// Delete moves a movie to the trash. Unless version is 0, the movie must
+	// be at that version.
+	Delete(ctx context.Context, id uint, version int) error
+}
+
+// UserRepository stores user accounts.
+type UserRepository interface {
+	// Transaction runs fn in a transaction. The repositories run their
+	// queries in it when they are given the context passed to fn.
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	Get(ctx context.Context, id uint) (*models.User, error)
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	// Create adds user, or returns ErrDuplicate if the username is taken.
+	Create(ctx context.Context, user *models.User) error
+	UpdateRole(ctx context.Context, id uint, role string) error
+	// CountByRole returns how many users have role.
+	CountByRole(ctx context.Context, role string) (int64, error)
+}
+
+type (
+	txKey      struct{}
+	replicaKey struct{}
+)
+
+// WithTx returns a context in which the GORM repositories run their queries
+// in tx, so that they take part in a transaction started elsewhere.
+func WithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// WithReplica returns a context in which the GORM repositories read from a
+// read replica, when the database has any; see db.ReplicaResolver. Replicas
+// can lag behind, so only reads that may miss the latest writes should use
+// it. Writes, and queries in a transaction, still go to the primary.
+func WithReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaKey{}, true)
+}
+
+// conn returns the transaction in ctx, or base, reading from a replica if
+// ctx asks for one.
+func conn(ctx context.Context, base *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	if ctx.Value(replicaKey{}) != nil {
+		return base.WithContext(ctx).Clauses(dbresolver.Use(db.ReplicaResolver))
+	}
+	return base.WithContext(ctx)
+}
+
+// transaction runs fn in a transaction on the primary of base, or in the
+// one already in ctx.
+func transaction(ctx context.Context, base *gorm.DB, fn func(ctx context.Context) error) error {
+	db, ok := ctx.Value(txKey{}).(*gorm.DB)
+	if !ok {
+		db = base.WithContext(ctx)
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		return fn(WithTx(ctx, tx))
+	})
+}
+
+// notFound turns gorm.ErrRecordNotFound into ErrNotFound.
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern, with a backslash.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ContainsPattern returns a LIKE pattern that matches text containing s in
+// lower case, taking any % and _ in s literally. Use it as the argument of
+// LOWER(column) LIKE ? ESCAPE '\'.
+func ContainsPattern(s string) string {
+	return "%" + likeEscaper.Replace(strings.ToLower(s)) + "%"
+}
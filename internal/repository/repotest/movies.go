@@ -0,0 +1,265 @@
+// Package repotest is the contract suite of the repository interfaces. Each
+// implementation runs it from a test of its own, e.g.
+//
+//	func TestMovieRepository(t *testing.T) {
+//		repotest.TestMovieRepository(t, func(t *testing.T) repository.MovieRepository {
+//			return repository.NewGormMovieRepository(openTestDatabase(t))
+//		})
+//	}
+//
+// The function passed in must return a repository over an empty store.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/repository"
+)
+
+// TestMovieRepository checks that the repositories returned by open behave
+// as MovieRepository documents.
+func TestMovieRepository(t *testing.T, open func(t *testing.T) repository.MovieRepository) {
+	ctx := context.Background()
+
+	// seed adds movies titled "Heat" (1995), "Alien" (1979) and "Aliens"
+	// (1986) and returns them in that order.
+	seed := func(t *testing.T, repo repository.MovieRepository) []*models.Movie {
+		t.Helper()
+		movies := []*models.Movie{
+			{Title: "Heat", Director: "Michael Mann", Year: 1995, Plot: "A heist"},
+			{Title: "Alien", Director: "Ridley Scott", Year: 1979},
+			{Title: "Aliens", Director: "James Cameron", Year: 1986},
+		}
+		for _, movie := range movies {
+			if err := repo.Create(ctx, movie); err != nil {
+				t.Fatalf("Create(%q): %v", movie.Title, err)
+			}
+			if movie.ID == 0 {
+				t.Fatalf("Create(%q) did not set the ID", movie.Title)
+			}
+		}
+		return movies
+	}
+
+	titles := func(movies []models.Movie) []string {
+		result := make([]string, len(movies))
+		for i, movie := range movies {
+			result[i] = movie.Title
+		}
+		return result
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := open(t)
+		heat := seed(t, repo)[0]
+
+		got, err := repo.Get(ctx, heat.ID)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got.Title != "Heat" || got.Director != "Michael Mann" || got.Year != 1995 || got.Plot != "A heist" {
+			t.Errorf("Get = %+v, want the created movie", got)
+		}
+		if got.Version != 1 {
+			t.Errorf("Version = %d, want 1", got.Version)
+		}
+		if got.CreatedAt.IsZero() {
+			t.Error("CreatedAt is not set")
+		}
+
+		if _, err := repo.Get(ctx, heat.ID+1000); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Get(unknown) error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		repo := open(t)
+		seed(t, repo)
+
+		tests := []struct {
+			name  string
+			query models.MovieListQuery
+			want  []string
+			total int64
+		}{
+			{"all", models.MovieListQuery{}, []string{"Heat", "Alien", "Aliens"}, 3},
+			{"title ignores case", models.MovieListQuery{Title: "ALIEN"}, []string{"Alien", "Aliens"}, 2},
+			{"director", models.MovieListQuery{Director: "scott"}, []string{"Alien"}, 1},
+			{"years", models.MovieListQuery{YearFrom: 1980, YearTo: 1995}, []string{"Heat", "Aliens"}, 2},
+			{"sort", models.MovieListQuery{Sort: "-year"}, []string{"Heat", "Aliens", "Alien"}, 3},
+			{"sort by several fields", models.MovieListQuery{Sort: "director, -title"}, []string{"Aliens", "Heat", "Alien"}, 3},
+			{"page", models.MovieListQuery{Sort: "title", Page: 2, Limit: 2}, []string{"Heat"}, 3},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				query := tt.query
+				if query.Page == 0 {
+					query.Page = 1
+				}
+				if query.Limit == 0 {
+					query.Limit = 20
+				}
+				movies, total, err := repo.List(ctx, &query)
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				if got := titles(movies); !equalStrings(got, tt.want) {
+					t.Errorf("List = %v, want %v", got, tt.want)
+				}
+				if total != tt.total {
+					t.Errorf("total = %d, want %d", total, tt.total)
+				}
+			})
+		}
+
+		query := models.MovieListQuery{Page: 1, Limit: 20, Sort: "budget"}
+		if _, _, err := repo.List(ctx, &query); !errors.Is(err, repository.ErrInvalidSort) {
+			t.Errorf("List(sort=budget) error = %v, want ErrInvalidSort", err)
+		}
+	})
+
+	t.Run("ListTakesWildcardsLiterally", func(t *testing.T) {
+		repo := open(t)
+		for _, title := range []string{"100% Wolf", "1000 Wolves", "Snake_Eyes", "Snake Eyes", `C:\Drive`} {
+			movie := models.Movie{Title: title, Director: title, Year: 2000}
+			if err := repo.Create(ctx, &movie); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+
+		tests := []struct {
+			filter string
+			want   []string
+		}{
+			{"0%", []string{"100% Wolf"}},
+			{"%", []string{"100% Wolf"}},
+			{"e_e", []string{"Snake_Eyes"}},
+			{`\`, []string{`C:\Drive`}},
+			{`\%`, []string{}},
+		}
+		for _, tt := range tests {
+			for _, query := range []models.MovieListQuery{{Title: tt.filter}, {Director: tt.filter}} {
+				query.Page, query.Limit = 1, 20
+				movies, _, err := repo.List(ctx, &query)
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				if got := titles(movies); !equalStrings(got, tt.want) {
+					t.Errorf("List(%+v) = %v, want %v", query, got, tt.want)
+				}
+			}
+		}
+	})
+
+	t.Run("Each", func(t *testing.T) {
+		repo := open(t)
+		seed(t, repo)
+
+		var batches [][]string
+		err := repo.Each(ctx, &models.MovieListQuery{}, 2, func(movies []models.Movie) error {
+			batches = append(batches, titles(movies))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Each: %v", err)
+		}
+		if len(batches) != 2 || !equalStrings(batches[0], []string{"Heat", "Alien"}) || !equalStrings(batches[1], []string{"Aliens"}) {
+			t.Errorf("Each batches = %v, want [[Heat Alien] [Aliens]]", batches)
+		}
+
+		stop := errors.New("stop")
+		err = repo.Each(ctx, &models.MovieListQuery{Title: "alien"}, 1, func(movies []models.Movie) error {
+			return stop
+		})
+		if !errors.Is(err, stop) {
+			t.Errorf("Each error = %v, want the error returned by fn", err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := open(t)
+		heat := seed(t, repo)[0]
+
+		movie, err := repo.GetForUpdate(ctx, heat.ID, 1)
+		if err != nil {
+			t.Fatalf("GetForUpdate: %v", err)
+		}
+		movie.Title = "Heat (1995)"
+		movie.Plot = ""
+		if err := repo.Update(ctx, movie); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if movie.Version != 2 {
+			t.Errorf("Version after Update = %d, want 2", movie.Version)
+		}
+
+		got, err := repo.Get(ctx, heat.ID)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got.Title != "Heat (1995)" || got.Plot != "" || got.Year != 1995 || got.Version != 2 {
+			t.Errorf("Get after Update = %+v", got)
+		}
+
+		if _, err := repo.GetForUpdate(ctx, heat.ID, 1); !errors.Is(err, repository.ErrVersionMismatch) {
+			t.Errorf("GetForUpdate(old version) error = %v, want ErrVersionMismatch", err)
+		}
+		if _, err := repo.GetForUpdate(ctx, heat.ID+1000, 0); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("GetForUpdate(unknown) error = %v, want ErrNotFound", err)
+		}
+
+		// A movie read before someone else's update cannot overwrite it.
+		stale := *movie
+		stale.Version = 1
+		stale.Title = "Stale"
+		if err := repo.Update(ctx, &stale); !errors.Is(err, repository.ErrVersionMismatch) {
+			t.Errorf("Update(stale) error = %v, want ErrVersionMismatch", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := open(t)
+		movies := seed(t, repo)
+
+		if err := repo.Delete(ctx, movies[0].ID, 2); !errors.Is(err, repository.ErrVersionMismatch) {
+			t.Errorf("Delete(wrong version) error = %v, want ErrVersionMismatch", err)
+		}
+		if err := repo.Delete(ctx, movies[0].ID, 1); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if err := repo.Delete(ctx, movies[1].ID, 0); err != nil {
+			t.Fatalf("Delete(any version): %v", err)
+		}
+
+		if _, err := repo.Get(ctx, movies[0].ID); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Get(deleted) error = %v, want ErrNotFound", err)
+		}
+		if err := repo.Delete(ctx, movies[0].ID, 0); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Delete(deleted) error = %v, want ErrNotFound", err)
+		}
+		query := models.MovieListQuery{Page: 1, Limit: 20}
+		remaining, total, err := repo.List(ctx, &query)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if total != 1 || !equalStrings(titles(remaining), []string{"Aliens"}) {
+			t.Errorf("List after Delete = %v (total %d), want [Aliens]", titles(remaining), total)
+		}
+	})
+
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
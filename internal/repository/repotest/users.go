@@ -0,0 +1,115 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/repository"
+)
+
+// TestUserRepository checks that the repositories returned by open behave
+// as UserRepository documents.
+func TestUserRepository(t *testing.T, open func(t *testing.T) repository.UserRepository) {
+	ctx := context.Background()
+
+	create := func(t *testing.T, repo repository.UserRepository, username, role string) *models.User {
+		t.Helper()
+		user := &models.User{Username: username, Password: "hash", Role: role}
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Create(%q): %v", username, err)
+		}
+		if user.ID == 0 {
+			t.Fatalf("Create(%q) did not set the ID", username)
+		}
+		return user
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := open(t)
+		alice := create(t, repo, "alice", models.RoleViewer)
+
+		got, err := repo.Get(ctx, alice.ID)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got.Username != "alice" || got.Password != "hash" || got.Role != models.RoleViewer {
+			t.Errorf("Get = %+v, want the created user", got)
+		}
+
+		got, err = repo.GetByUsername(ctx, "alice")
+		if err != nil {
+			t.Fatalf("GetByUsername: %v", err)
+		}
+		if got.ID != alice.ID {
+			t.Errorf("GetByUsername ID = %d, want %d", got.ID, alice.ID)
+		}
+
+		if _, err := repo.Get(ctx, alice.ID+1000); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Get(unknown) error = %v, want ErrNotFound", err)
+		}
+		if _, err := repo.GetByUsername(ctx, "bob"); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("GetByUsername(unknown) error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("DuplicateUsername", func(t *testing.T) {
+		repo := open(t)
+		create(t, repo, "alice", models.RoleViewer)
+
+		err := repo.Create(ctx, &models.User{Username: "alice", Password: "other", Role: models.RoleViewer})
+		if !errors.Is(err, repository.ErrDuplicate) {
+			t.Errorf("Create(duplicate) error = %v, want ErrDuplicate", err)
+		}
+	})
+
+	t.Run("Roles", func(t *testing.T) {
+		repo := open(t)
+		alice := create(t, repo, "alice", models.RoleViewer)
+		create(t, repo, "bob", models.RoleViewer)
+
+		if err := repo.UpdateRole(ctx, alice.ID, models.RoleAdmin); err != nil {
+			t.Fatalf("UpdateRole: %v", err)
+		}
+		got, err := repo.Get(ctx, alice.ID)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got.Role != models.RoleAdmin {
+			t.Errorf("Role = %q, want %q", got.Role, models.RoleAdmin)
+		}
+
+		for role, want := range map[string]int64{models.RoleAdmin: 1, models.RoleViewer: 1, models.RoleEditor: 0} {
+			count, err := repo.CountByRole(ctx, role)
+			if err != nil {
+				t.Fatalf("CountByRole(%q): %v", role, err)
+			}
+			if count != want {
+				t.Errorf("CountByRole(%q) = %d, want %d", role, count, want)
+			}
+		}
+
+		if err := repo.UpdateRole(ctx, alice.ID+1000, models.RoleAdmin); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("UpdateRole(unknown) error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Transaction", func(t *testing.T) {
+		repo := open(t)
+		failed := errors.New("failed")
+
+		err := repo.Transaction(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, &models.User{Username: "carol", Password: "hash", Role: models.RoleViewer}); err != nil {
+				return err
+			}
+			return failed
+		})
+		if !errors.Is(err, failed) {
+			t.Fatalf("Transaction error = %v, want the error returned by fn", err)
+		}
+		if _, err := repo.GetByUsername(ctx, "carol"); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("GetByUsername after rollback error = %v, want ErrNotFound", err)
+		}
+	})
+}
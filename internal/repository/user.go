@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+// GormUserRepository is a UserRepository on a GORM database.
+type GormUserRepository struct {
+	db *gorm.DB
+}
+
+func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
+	return &GormUserRepository{db: db}
+}
+
+func (r *GormUserRepository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return transaction(ctx, r.db, fn)
+}
+
+func (r *GormUserRepository) Get(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := conn(ctx, r.db).First(&user, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *GormUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	var user models.User
+	if err := conn(ctx, r.db).Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *GormUserRepository) Create(ctx context.Context, user *models.User) error {
+	return transaction(ctx, r.db, func(ctx context.Context) error {
+		// Deleted accounts keep their username, as the unique index does.
+		var count int64
+		err := conn(ctx, r.db).Unscoped().Model(&models.User{}).Where("username = ?", user.Username).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDuplicate
+		}
+		return conn(ctx, r.db).Create(user).Error
+	})
+}
+
+func (r *GormUserRepository) UpdateRole(ctx context.Context, id uint, role string) error {
+	result := conn(ctx, r.db).Model(&models.User{}).Where("id = ?", id).Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *GormUserRepository) CountByRole(ctx context.Context, role string) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).Model(&models.User{}).Where("role = ?", role).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+var (
+	ErrMovieNotFound  = errors.New("movie not found")
+	ErrCreditNotFound = errors.New("credit not found")
+)
+
+type CreditService struct {
+	db *gorm.DB
+}
+
+func NewCreditService(db *gorm.DB) *CreditService {
+	return &CreditService{db: db}
+}
+
+// GetMovieCredits returns the credits of a movie grouped by role and billing
+// order, with each person preloaded.
+func (s *CreditService) GetMovieCredits(movieID uint) ([]models.Credit, error) {
+	if err := ensureMovieExists(s.db, movieID); err != nil {
+		return nil, err
+	}
+
+	credits := []models.Credit{}
+	result := s.db.
+		Preload("Person").
+		Where("movie_id = ?", movieID).
+		Order("role ASC, billing_order ASC, id ASC").
+		Find(&credits)
+	return credits, result.Error
+}
+
+func (s *CreditService) AddCredit(movieID uint, req *models.CreditRequest) (*models.Credit, error) {
+	credit := models.Credit{
+		MovieID:      movieID,
+		PersonID:     req.PersonID,
+		Role:         req.Role,
+		BillingOrder: req.BillingOrder,
+	}
+	if req.Role == models.CreditRoleActor {
+		credit.Character = req.Character
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureMovieExists(tx, movieID); err != nil {
+			return err
+		}
+
+		var person models.Person
+		if err := tx.First(&person, req.PersonID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPersonNotFound
+			}
+			return err
+		}
+		credit.Person = &person
+
+		if err := tx.Omit("Person", "Movie").Create(&credit).Error; err != nil {
+			return err
+		}
+
+		if credit.Role == models.CreditRoleDirector {
+			return syncMovieDirector(tx, movieID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &credit, nil
+}
+
+func (s *CreditService) DeleteCredit(movieID, creditID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var credit models.Credit
+		err := tx.Where("id = ? AND movie_id = ?", creditID, movieID).First(&credit).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCreditNotFound
+			}
+			return err
+		}
+
+		if err := tx.Delete(&credit).Error; err != nil {
+			return err
+		}
+
+		if credit.Role == models.CreditRoleDirector {
+			return syncMovieDirector(tx, movieID)
+		}
+		return nil
+	})
+}
+
+// ensureMovieExists returns ErrMovieNotFound unless a movie with the given ID
+// exists and has not been deleted.
+func ensureMovieExists(tx *gorm.DB, movieID uint) error {
+	var count int64
+	if err := tx.Model(&models.Movie{}).Where("id = ?", movieID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrMovieNotFound
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+var (
+	ErrGenreExists   = errors.New("genre already exists")
+	ErrGenreNotFound = errors.New("genre not found")
+)
+
+type GenreService struct {
+	db *gorm.DB
+}
+
+func NewGenreService(db *gorm.DB) *GenreService {
+	return &GenreService{db: db}
+}
+
+func (s *GenreService) GetAllGenres() ([]models.Genre, error) {
+	genres := []models.Genre{}
+	result := s.db.Order("name").Find(&genres)
+	return genres, result.Error
+}
+
+func (s *GenreService) GetGenreByID(id uint) (*models.Genre, error) {
+	var genre models.Genre
+	result := s.db.First(&genre, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &genre, nil
+}
+
+func (s *GenreService) CreateGenre(req *models.GenreRequest) (*models.Genre, error) {
+	genre := models.Genre{Name: req.Name}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureGenreNameFree(tx, req.Name, 0); err != nil {
+			return err
+		}
+		return tx.Create(&genre).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &genre, nil
+}
+
+func (s *GenreService) UpdateGenre(id uint, req *models.GenreRequest) (*models.Genre, error) {
+	var genre models.Genre
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&genre, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrGenreNotFound
+			}
+			return err
+		}
+		if err := ensureGenreNameFree(tx, req.Name, id); err != nil {
+			return err
+		}
+
+		genre.Name = req.Name
+		return tx.Save(&genre).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &genre, nil
+}
+
+// DeleteGenre removes the genre and detaches it from every movie.
+func (s *GenreService) DeleteGenre(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM movie_genres WHERE genre_id = ?", id).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&models.Genre{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrGenreNotFound
+		}
+		return nil
+	})
+}
+
+// ensureGenreNameFree returns ErrGenreExists if a genre other than exceptID
+// already uses name, compared case-insensitively.
+func ensureGenreNameFree(tx *gorm.DB, name string, exceptID uint) error {
+	var count int64
+	err := tx.Model(&models.Genre{}).
+		Where("LOWER(name) = LOWER(?) AND id <> ?", name, exceptID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrGenreExists
+	}
+	return nil
+}
+
+// findGenres loads the genres with the given IDs, returning ErrGenreNotFound
+// if any of them does not exist.
+func findGenres(tx *gorm.DB, ids []uint) ([]models.Genre, error) {
+	genres := []models.Genre{}
+	if len(ids) == 0 {
+		return genres, nil
+	}
+
+	if err := tx.Where("id IN ?", ids).Find(&genres).Error; err != nil {
+		return nil, err
+	}
+
+	unique := make(map[uint]struct{}, len(ids))
+	for _, id := range ids {
+		unique[id] = struct{}{}
+	}
+	if len(genres) != len(unique) {
+		return nil, ErrGenreNotFound
+	}
+	return genres, nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+var ErrWatchedEntryNotFound = errors.New("watched entry not found")
+
+type HistoryService struct {
+	db *gorm.DB
+}
+
+func NewHistoryService(db *gorm.DB) *HistoryService {
+	return &HistoryService{db: db}
+}
+
+// GetHistory returns the movies the user watched, most recent first,
+// skipping deleted movies.
+func (s *HistoryService) GetHistory(userID uint, query *models.WatchedListQuery) ([]models.WatchedEntry, int64, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 {
+		query.Limit = DefaultMoviePageSize
+	}
+
+	filtered := s.db.Model(&models.WatchedEntry{}).
+		InnerJoins("Movie").
+		Where("watched_entries.user_id = ?", userID).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := filtered.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	entries := []models.WatchedEntry{}
+	result := filtered.
+		Order("watched_entries.watched_on DESC, watched_entries.id DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&entries)
+	return entries, total, result.Error
+}
+
+func (s *HistoryService) AddWatchedEntry(userID uint, req *models.WatchedEntryRequest) (*models.WatchedEntry, error) {
+	watchedOn := time.Now().UTC().Truncate(24 * time.Hour)
+	if req.WatchedOn != "" {
+		var err error
+		if watchedOn, err = time.Parse(time.DateOnly, req.WatchedOn); err != nil {
+			return nil, err
+		}
+	}
+
+	entry := models.WatchedEntry{
+		UserID:    userID,
+		MovieID:   req.MovieID,
+		WatchedOn: watchedOn,
+		Rewatch:   req.Rewatch,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureMovieExists(tx, req.MovieID); err != nil {
+			return err
+		}
+		return tx.Omit("Movie", "User").Create(&entry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (s *HistoryService) DeleteWatchedEntry(userID, entryID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", entryID, userID).Delete(&models.WatchedEntry{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWatchedEntryNotFound
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mehmonov/movies-crud/config"
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/storage"
+	"github.com/mehmonov/movies-crud/pkg/mp4"
+)
+
+var (
+	ErrHLSNotReady     = errors.New("HLS rendition is not ready")
+	ErrHLSFileNotFound = errors.New("HLS file not found")
+)
+
+const (
+	HLSPlaylistName = "index.m3u8"
+	hlsInitName     = "init.mp4"
+)
+
+// hlsSegmentName matches the segment names written by the packager.
+var hlsSegmentName = regexp.MustCompile(`^seg_[0-9]{5,}\.m4s$`)
+
+// hlsPollInterval is how often the worker looks for queued jobs when it is
+// not woken up, which picks up jobs queued by other instances.
+const hlsPollInterval = time.Minute
+
+// HLSService packages uploaded MP4 files for HLS in a background worker.
+// Each file is split on keyframes into fMP4 segments stored next to it under
+// movie_<id>/hls/<media id>/, with an init.mp4 and an index.m3u8 playlist.
+// Jobs are tracked through the HLSStatus of the media file, so they survive
+// restarts.
+type HLSService struct {
+	db              *gorm.DB
+	store           storage.BlobStore
+	segmentDuration time.Duration
+
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewHLSService(cfg *config.Config, db *gorm.DB, store storage.BlobStore) *HLSService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &HLSService{
+		db:              db,
+		store:           store,
+		segmentDuration: cfg.Media.HLSSegmentDuration,
+		wake:            make(chan struct{}, 1),
+		ctx:             ctx,
+		cancel:          cancel,
+		done:            make(chan struct{}),
+	}
+}
+
+// Start requeues jobs interrupted by a previous shutdown, queues MP4 files
+// uploaded before packaging existed and starts the worker.
+func (s *HLSService) Start() error {
+	err := s.db.Model(&models.MediaFile{}).
+		Where("hls_status = ?", models.HLSStatusProcessing).
+		Update("hls_status", models.HLSStatusQueued).Error
+	if err != nil {
+		return err
+	}
+	err = s.db.Model(&models.MediaFile{}).
+		Where("content_type = ? AND (hls_status = '' OR hls_status IS NULL)", "video/mp4").
+		Update("hls_status", models.HLSStatusQueued).Error
+	if err != nil {
+		return err
+	}
+
+	go s.run()
+	return nil
+}
+
+// Stop stops the worker. A job in progress is abandoned and picked up again
+// on the next start.
+func (s *HLSService) Stop(ctx context.Context) error {
+	s.cancel()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Enqueue wakes the worker up after a media file was queued.
+func (s *HLSService) Enqueue() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// OpenFile opens a playlist, init segment or media segment of the HLS
+// rendition of a media file.
+func (s *HLSService) OpenFile(ctx context.Context, movieID, mediaID uint, name string) (storage.Blob, error) {
+	if name != HLSPlaylistName && name != hlsInitName && !hlsSegmentName.MatchString(name) {
+		return nil, ErrHLSFileNotFound
+	}
+	if err := ensureMovieExists(s.db, movieID); err != nil {
+		return nil, err
+	}
+
+	var media models.MediaFile
+	err := s.db.Where("id = ? AND movie_id = ?", mediaID, movieID).First(&media).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMediaNotFound
+		}
+		return nil, err
+	}
+	if media.HLSStatus != models.HLSStatusReady {
+		return nil, ErrHLSNotReady
+	}
+
+	blob, err := s.store.Open(ctx, hlsKey(&media, name))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrHLSFileNotFound
+		}
+		return nil, err
+	}
+	return blob, nil
+}
+
+// DeleteFiles removes the HLS rendition of a media file.
+func (s *HLSService) DeleteFiles(ctx context.Context, media *models.MediaFile) error {
+	names := []string{HLSPlaylistName, hlsInitName}
+	for i := 1; i <= media.HLSSegments; i++ {
+		names = append(names, hlsSegment(i))
+	}
+	for _, name := range names {
+		if err := s.store.Delete(ctx, hlsKey(media, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *HLSService) run() {
+	defer close(s.done)
+
+	for {
+		for s.processNext() {
+		}
+
+		select {
+		case <-s.wake:
+		case <-time.After(hlsPollInterval):
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// processNext runs the oldest queued job, if any, and reports whether there
+// may be more.
+func (s *HLSService) processNext() bool {
+	if s.ctx.Err() != nil {
+		return false
+	}
+
+	var media models.MediaFile
+	claimed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// SKIP LOCKED lets several instances share the queue. Find rather
+		// than First, as an empty queue is the usual case and First would
+		// log it as an error.
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("hls_status = ?", models.HLSStatusQueued).
+			Order("id").
+			Limit(1).
+			Find(&media)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		claimed = true
+		return tx.Model(&media).Updates(map[string]interface{}{
+			"hls_status": models.HLSStatusProcessing,
+			"hls_error":  "",
+		}).Error
+	})
+	if err != nil {
+		log.Printf("HLS: claiming a job: %v", err)
+		return false
+	}
+	if !claimed {
+		return false
+	}
+
+	segments, err := s.pack(s.ctx, &media)
+	if s.ctx.Err() != nil {
+		// Shutting down; the job is requeued on the next start.
+		return false
+	}
+
+	updates := map[string]interface{}{
+		"hls_status":   models.HLSStatusReady,
+		"hls_segments": segments,
+	}
+	if err != nil {
+		log.Printf("HLS: packaging media %d: %v", media.ID, err)
+		updates = map[string]interface{}{
+			"hls_status": models.HLSStatusFailed,
+			"hls_error":  truncate(err.Error(), 500),
+		}
+		media.HLSSegments = segments
+		if err := s.DeleteFiles(context.Background(), &media); err != nil {
+			log.Printf("HLS: deleting the files of failed media %d: %v", media.ID, err)
+		}
+		segments = 0
+	}
+
+	result := s.db.Model(&models.MediaFile{}).
+		Where("id = ? AND hls_status = ?", media.ID, models.HLSStatusProcessing).
+		Updates(updates)
+	if result.Error != nil {
+		log.Printf("HLS: updating media %d: %v", media.ID, result.Error)
+	} else if result.RowsAffected == 0 {
+		// The media file was deleted meanwhile.
+		media.HLSSegments = segments
+		if err := s.DeleteFiles(context.Background(), &media); err != nil {
+			log.Printf("HLS: deleting the files of deleted media %d: %v", media.ID, err)
+		}
+	}
+	return true
+}
+
+// pack writes the HLS rendition of media and returns its number of
+// segments. The playlist is written last, so it only exists once every
+// segment does.
+func (s *HLSService) pack(ctx context.Context, media *models.MediaFile) (int, error) {
+	blob, err := s.store.Open(ctx, media.StorageKey)
+	if err != nil {
+		return 0, err
+	}
+	defer blob.Close()
+
+	file, err := mp4.Open(blob, blob.Info().Size)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := s.store.Put(ctx, hlsKey(media, hlsInitName), bytes.NewReader(file.InitSegment())); err != nil {
+		return 0, err
+	}
+
+	segments := file.Segments(s.segmentDuration)
+	var playlist strings.Builder
+	var target float64
+	var buf bytes.Buffer
+	for i, segment := range segments {
+		buf.Reset()
+		if err := file.WriteSegment(&buf, uint32(i+1), segment); err != nil {
+			return i, err
+		}
+		if _, err := s.store.Put(ctx, hlsKey(media, hlsSegment(i+1)), &buf); err != nil {
+			return i, err
+		}
+
+		seconds := segment.Duration.Seconds()
+		target = math.Max(target, seconds)
+		fmt.Fprintf(&playlist, "#EXTINF:%.3f,\n%s\n", seconds, hlsSegment(i+1))
+	}
+
+	header := fmt.Sprintf("#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-TARGETDURATION:%d\n#EXT-X-MEDIA-SEQUENCE:1\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXT-X-INDEPENDENT-SEGMENTS\n#EXT-X-MAP:URI=\"%s\"\n",
+		int(math.Ceil(target)), hlsInitName)
+	content := header + playlist.String() + "#EXT-X-ENDLIST\n"
+	if _, err := s.store.Put(ctx, hlsKey(media, HLSPlaylistName), strings.NewReader(content)); err != nil {
+		return len(segments), err
+	}
+	return len(segments), nil
+}
+
+func hlsKey(media *models.MediaFile, name string) string {
+	return fmt.Sprintf("movie_%d/hls/%d/%s", media.MovieID, media.ID, name)
+}
+
+func hlsSegment(n int) string {
+	return fmt.Sprintf("seg_%05d.m4s", n)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/mehmonov/movies-crud/config"
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/storage"
+)
+
+// testHLSService returns an HLSService whose log output, and that of its
+// queries, goes to the returned buffer.
+func testHLSService(t *testing.T) (*HLSService, *bytes.Buffer) {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Storage.UploadDir = t.TempDir()
+	var out bytes.Buffer
+	database := testDatabase(t, cfg).Session(&gorm.Session{
+		Logger: logger.New(log.New(&out, "", 0), logger.Config{LogLevel: logger.Warn}),
+	})
+
+	previous := log.Writer()
+	log.SetOutput(&out)
+	t.Cleanup(func() { log.SetOutput(previous) })
+
+	store, err := storage.NewLocalBlobStore(cfg.Storage.UploadDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewHLSService(cfg, database, store), &out
+}
+
+func TestHLSProcessNextIdle(t *testing.T) {
+	service, out := testHLSService(t)
+	if service.processNext() {
+		t.Error("processNext reported a job on an empty queue")
+	}
+	if out.Len() != 0 {
+		t.Errorf("an empty queue logged %q", out.String())
+	}
+}
+
+func TestHLSProcessNextFails(t *testing.T) {
+	service, out := testHLSService(t)
+	movie := models.Movie{Title: "Heat", Year: 1995}
+	if err := service.db.Create(&movie).Error; err != nil {
+		t.Fatal(err)
+	}
+	media := models.MediaFile{
+		MovieID:     movie.ID,
+		StorageKey:  "movie_1/missing.mp4",
+		ContentType: "video/mp4",
+		SHA256:      "missing",
+		HLSStatus:   models.HLSStatusQueued,
+	}
+	if err := service.db.Create(&media).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if !service.processNext() {
+		t.Fatal("processNext did not claim the queued job")
+	}
+	if err := service.db.First(&media, media.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if media.HLSStatus != models.HLSStatusFailed || media.HLSError == "" {
+		t.Errorf("got status %q, error %q; want a failed job", media.HLSStatus, media.HLSError)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("packaging media")) {
+		t.Errorf("the failure was not logged: %q", out.String())
+	}
+	if service.processNext() {
+		t.Error("processNext reported a job once the queue was empty")
+	}
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/mehmonov/movies-crud/config"
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/storage"
+	"github.com/mehmonov/movies-crud/pkg/imaging"
+)
+
+var (
+	ErrImageNotFound    = errors.New("image not found")
+	ErrInvalidImageKind = errors.New("image kind must be poster or backdrop")
+	ErrImageTooLarge    = errors.New("image exceeds the upload size limit")
+)
+
+// thumbnailWidths are the widths, in pixels, of the thumbnails generated for
+// each kind of image. Widths larger than the original are skipped.
+var thumbnailWidths = map[string][]int{
+	models.ImageKindPoster:   {92, 185, 342, 500, 780},
+	models.ImageKindBackdrop: {300, 780, 1280},
+}
+
+// ImageService stores movie posters and backdrops with their thumbnails.
+// Files are kept in the same BlobStore as media, under
+// movie_<id>/images/<kind>_<sha256>/, named after the SHA-256 of the
+// upload.
+type ImageService struct {
+	db      *gorm.DB
+	store   storage.BlobStore
+	maxSize int64
+}
+
+func NewImageService(cfg *config.Config, db *gorm.DB, store storage.BlobStore) *ImageService {
+	return &ImageService{
+		db:      db,
+		store:   store,
+		maxSize: cfg.Storage.MaxImageSize,
+	}
+}
+
+// MaxUploadSize is the largest image, in bytes, that can be uploaded.
+func (s *ImageService) MaxUploadSize() int64 {
+	return s.maxSize
+}
+
+// SetImage stores the image read from r as the movie's poster or backdrop,
+// replacing the previous one.
+func (s *ImageService) SetImage(ctx context.Context, movieID uint, kind string, r io.Reader) (*models.MovieImage, error) {
+	widths, ok := thumbnailWidths[kind]
+	if !ok {
+		return nil, ErrInvalidImageKind
+	}
+	if err := ensureMovieExists(s.db, movieID); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, ErrImageTooLarge
+	}
+
+	img, format, err := imaging.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	image := models.MovieImage{
+		MovieID:    movieID,
+		Kind:       kind,
+		StorageKey: fmt.Sprintf("movie_%d/images/%s_%s", movieID, kind, hex.EncodeToString(sum[:])),
+		Format:     format,
+		Width:      img.Bounds().Dx(),
+		Height:     img.Bounds().Dy(),
+	}
+
+	// Re-encoding the original drops its EXIF data.
+	if err := s.put(ctx, &image, "original", img); err != nil {
+		return nil, err
+	}
+	var thumbnails []string
+	for _, width := range widths {
+		if width >= image.Width {
+			break
+		}
+		name := "w" + strconv.Itoa(width)
+		if err := s.put(ctx, &image, name, imaging.Resize(img, width)); err != nil {
+			return nil, err
+		}
+		thumbnails = append(thumbnails, strconv.Itoa(width))
+	}
+	image.Thumbnails = strings.Join(thumbnails, ",")
+
+	var previous []models.MovieImage
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockMovie(tx, movieID); err != nil {
+			return err
+		}
+		if err := tx.Where("movie_id = ? AND kind = ?", movieID, kind).Find(&previous).Error; err != nil {
+			return err
+		}
+		if len(previous) > 0 {
+			if err := tx.Delete(&previous).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&image).Error
+	})
+	if err != nil {
+		if image.StorageKey != "" && !sameKey(previous, image.StorageKey) {
+			s.deleteFiles(ctx, &image)
+		}
+		return nil, err
+	}
+
+	for i := range previous {
+		// Uploading the same image again reuses its files.
+		if previous[i].StorageKey != image.StorageKey {
+			s.deleteFiles(ctx, &previous[i])
+		}
+	}
+	return &image, nil
+}
+
+// OpenImage returns the image with the stored file of one of its sizes. The
+// caller must close the blob.
+func (s *ImageService) OpenImage(ctx context.Context, movieID, imageID uint, size string) (*models.MovieImage, storage.Blob, error) {
+	var image models.MovieImage
+	err := s.db.Where("id = ? AND movie_id = ?", imageID, movieID).First(&image).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrImageNotFound
+		}
+		return nil, nil, err
+	}
+	if _, ok := image.URLs[size]; !ok {
+		return nil, nil, ErrImageNotFound
+	}
+
+	blob, err := s.store.Open(ctx, imageKey(&image, size))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrImageNotFound
+		}
+		return nil, nil, err
+	}
+	return &image, blob, nil
+}
+
+// DeleteImage removes the movie's poster or backdrop.
+func (s *ImageService) DeleteImage(ctx context.Context, movieID uint, kind string) error {
+	if _, ok := thumbnailWidths[kind]; !ok {
+		return ErrInvalidImageKind
+	}
+
+	var images []models.MovieImage
+	if err := s.db.Where("movie_id = ? AND kind = ?", movieID, kind).Find(&images).Error; err != nil {
+		return err
+	}
+	if len(images) == 0 {
+		return ErrImageNotFound
+	}
+	if err := s.db.Delete(&images).Error; err != nil {
+		return err
+	}
+
+	for i := range images {
+		s.deleteFiles(ctx, &images[i])
+	}
+	return nil
+}
+
+func (s *ImageService) put(ctx context.Context, image *models.MovieImage, size string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, image.Format); err != nil {
+		return err
+	}
+	_, err := s.store.Put(ctx, imageKey(image, size), &buf)
+	return err
+}
+
+// deleteFiles removes the stored sizes of image. Failures are only logged,
+// as the record is already gone.
+func (s *ImageService) deleteFiles(ctx context.Context, image *models.MovieImage) {
+	for _, size := range image.Sizes() {
+		if err := s.store.Delete(ctx, imageKey(image, size)); err != nil {
+			log.Printf("Deleting image file %s: %v", imageKey(image, size), err)
+		}
+	}
+}
+
+func imageKey(image *models.MovieImage, size string) string {
+	return image.StorageKey + "/" + size + imaging.Extension(image.Format)
+}
+
+func sameKey(images []models.MovieImage, key string) bool {
+	for _, image := range images {
+		if image.StorageKey == key {
+			return true
+		}
+	}
+	return false
+}
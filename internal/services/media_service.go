@@ -0,0 +1,430 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mehmonov/movies-crud/config"
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/storage"
+)
+
+var (
+	ErrMediaNotFound         = errors.New("media file not found")
+	ErrUnsupportedMediaType  = errors.New("unsupported media type, expected an MP4 or WebM video")
+	ErrMediaTooLarge         = errors.New("file exceeds the upload size limit")
+	ErrUploadSessionNotFound = errors.New("upload session not found or expired")
+	ErrUploadOffsetMismatch  = errors.New("upload offset does not match the bytes received so far")
+	ErrUploadChunkTooLarge   = errors.New("chunk goes past the declared upload size")
+)
+
+// videoExtensions lists the accepted video types, as sniffed from the file
+// content, and the extension their files are stored with.
+var videoExtensions = map[string]string{
+	"video/mp4":  ".mp4",
+	"video/webm": ".webm",
+}
+
+// uploadSessionTTL is how long an unfinished resumable upload is kept.
+const uploadSessionTTL = 24 * time.Hour
+
+// MediaService stores uploaded movie videos in a BlobStore. Uploads are
+// first staged on local disk, where they are hashed and their type is
+// sniffed, and are then stored under movie_<id>/<hash><ext>.
+type MediaService struct {
+	db         *gorm.DB
+	store      storage.BlobStore
+	packager   *HLSService
+	stagingDir string
+	maxSize    int64
+}
+
+func NewMediaService(cfg *config.Config, db *gorm.DB, store storage.BlobStore, packager *HLSService) (*MediaService, error) {
+	stagingDir := filepath.Join(cfg.Storage.UploadDir, ".incoming")
+	if err := os.MkdirAll(stagingDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &MediaService{
+		db:         db,
+		store:      store,
+		packager:   packager,
+		stagingDir: stagingDir,
+		maxSize:    cfg.Storage.MaxUploadSize,
+	}, nil
+}
+
+// MaxUploadSize is the largest file, in bytes, that can be uploaded.
+func (s *MediaService) MaxUploadSize() int64 {
+	return s.maxSize
+}
+
+func (s *MediaService) GetMovieMedia(movieID uint) ([]models.MediaFile, error) {
+	if err := ensureMovieExists(s.db, movieID); err != nil {
+		return nil, err
+	}
+
+	media := []models.MediaFile{}
+	result := s.db.Where("movie_id = ?", movieID).Order("id").Find(&media)
+	return media, result.Error
+}
+
+// UploadMedia stores the video read from r for the movie. If the movie
+// already has a file with the same content, that file is returned and
+// created is false.
+func (s *MediaService) UploadMedia(ctx context.Context, movieID uint, filename string, r io.Reader) (media *models.MediaFile, created bool, err error) {
+	if err := ensureMovieExists(s.db, movieID); err != nil {
+		return nil, false, err
+	}
+
+	staged, err := os.CreateTemp(s.stagingDir, "upload-*")
+	if err != nil {
+		return nil, false, err
+	}
+	defer os.Remove(staged.Name())
+
+	n, err := io.Copy(staged, io.LimitReader(r, s.maxSize+1))
+	if closeErr := staged.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if n > s.maxSize {
+		return nil, false, ErrMediaTooLarge
+	}
+
+	return s.storeStaged(ctx, movieID, filename, staged.Name())
+}
+
+// CreateUploadSession starts a resumable upload of size bytes for the movie.
+func (s *MediaService) CreateUploadSession(movieID uint, req *models.UploadSessionRequest) (*models.UploadSession, error) {
+	if req.Size > s.maxSize {
+		return nil, ErrMediaTooLarge
+	}
+	if err := ensureMovieExists(s.db, movieID); err != nil {
+		return nil, err
+	}
+	if err := s.purgeExpiredSessions(); err != nil {
+		return nil, err
+	}
+
+	id, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	session := models.UploadSession{
+		ID:        id,
+		MovieID:   movieID,
+		Filename:  cleanFilename(req.Filename),
+		Size:      req.Size,
+		ExpiresAt: time.Now().Add(uploadSessionTTL),
+	}
+
+	f, err := os.Create(s.sessionPath(id))
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	if err := s.db.Create(&session).Error; err != nil {
+		os.Remove(s.sessionPath(id))
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (s *MediaService) GetUploadSession(movieID uint, id string) (*models.UploadSession, error) {
+	var session models.UploadSession
+	err := s.db.Where("id = ? AND movie_id = ? AND expires_at > ?", id, movieID, time.Now()).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUploadSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// AppendUploadChunk writes the bytes read from r at offset, which must equal
+// the number of bytes received so far. Bytes received before r fails are
+// kept, so the client can resume from the session's new Offset. Once the
+// whole file has been received it is stored and returned as media.
+//
+// The chunk is first staged in a file of its own, so the session row is only
+// locked while the staged bytes are appended, not while the client sends
+// them; a concurrent chunk for the same offset then loses the offset check.
+func (s *MediaService) AppendUploadChunk(ctx context.Context, movieID uint, id string, offset int64, r io.Reader) (session *models.UploadSession, media *models.MediaFile, err error) {
+	session, err = s.GetUploadSession(movieID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if offset != session.Offset {
+		return nil, nil, ErrUploadOffsetMismatch
+	}
+
+	chunk, err := os.CreateTemp(s.stagingDir, "chunk-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.Remove(chunk.Name())
+	defer chunk.Close()
+
+	remaining := session.Size - session.Offset
+	n, copyErr := io.Copy(chunk, io.LimitReader(r, remaining))
+	if copyErr == nil && n == remaining {
+		// Reject the whole chunk if the body goes on past the declared size.
+		var extra [1]byte
+		if m, _ := r.Read(extra[:]); m > 0 {
+			return nil, nil, ErrUploadChunkTooLarge
+		}
+	}
+	if n == 0 && copyErr != nil {
+		return nil, nil, copyErr
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND movie_id = ? AND expires_at > ?", id, movieID, time.Now()).
+			First(session).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUploadSessionNotFound
+			}
+			return err
+		}
+		if offset != session.Offset {
+			return ErrUploadOffsetMismatch
+		}
+
+		if err := appendChunk(s.sessionPath(id), offset, chunk); err != nil {
+			return err
+		}
+		session.Offset += n
+		return tx.Model(session).Update("offset", session.Offset).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if copyErr != nil {
+		return session, nil, copyErr
+	}
+	if session.Offset < session.Size {
+		return session, nil, nil
+	}
+
+	media, _, err = s.storeStaged(ctx, movieID, session.Filename, s.sessionPath(id))
+	if err != nil {
+		return session, nil, err
+	}
+
+	if err := s.db.Delete(session).Error; err != nil {
+		return session, nil, err
+	}
+	os.Remove(s.sessionPath(id))
+
+	return session, media, nil
+}
+
+// appendChunk copies the staged chunk into the session file at path,
+// starting at offset.
+func appendChunk(path string, offset int64, chunk *os.File) error {
+	if _, err := chunk.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	_, err = f.Seek(offset, io.SeekStart)
+	if err == nil {
+		_, err = io.Copy(f, chunk)
+	}
+	if err != nil {
+		f.Truncate(offset)
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *MediaService) GetMedia(movieID, mediaID uint) (*models.MediaFile, error) {
+	if err := ensureMovieExists(s.db, movieID); err != nil {
+		return nil, err
+	}
+
+	var media models.MediaFile
+	err := s.db.Where("id = ? AND movie_id = ?", mediaID, movieID).First(&media).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMediaNotFound
+		}
+		return nil, err
+	}
+	return &media, nil
+}
+
+// OpenMedia returns the media file of the movie together with its stored
+// content. The caller must close the blob.
+func (s *MediaService) OpenMedia(ctx context.Context, movieID, mediaID uint) (*models.MediaFile, storage.Blob, error) {
+	media, err := s.GetMedia(movieID, mediaID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blob, err := s.store.Open(ctx, media.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrMediaNotFound
+		}
+		return nil, nil, err
+	}
+	return media, blob, nil
+}
+
+// DeleteMedia removes the media file record and its stored file.
+func (s *MediaService) DeleteMedia(ctx context.Context, movieID, mediaID uint) error {
+	var media models.MediaFile
+	err := s.db.Where("id = ? AND movie_id = ?", mediaID, movieID).First(&media).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMediaNotFound
+		}
+		return err
+	}
+
+	if err := s.db.Delete(&media).Error; err != nil {
+		return err
+	}
+	if err := s.packager.DeleteFiles(ctx, &media); err != nil {
+		return err
+	}
+	return s.store.Delete(ctx, media.StorageKey)
+}
+
+// storeStaged validates the staged file at path, copies it to the blob store
+// and records it for the movie.
+func (s *MediaService) storeStaged(ctx context.Context, movieID uint, filename, path string) (*models.MediaFile, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	header := make([]byte, 512)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, false, err
+	}
+	contentType := http.DetectContentType(header[:n])
+	ext, ok := videoExtensions[contentType]
+	if !ok {
+		return nil, false, ErrUnsupportedMediaType
+	}
+
+	hash := sha256.New()
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, false, err
+	}
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return nil, false, err
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+
+	var existing models.MediaFile
+	err = s.db.Where("movie_id = ? AND sha256 = ?", movieID, sum).First(&existing).Error
+	if err == nil {
+		return &existing, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	key := fmt.Sprintf("movie_%d/%s%s", movieID, sum, ext)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, false, err
+	}
+	if _, err := s.store.Put(ctx, key, f); err != nil {
+		return nil, false, err
+	}
+
+	media := models.MediaFile{
+		MovieID:     movieID,
+		StorageKey:  key,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      sum,
+	}
+	if contentType == "video/mp4" {
+		media.HLSStatus = models.HLSStatusQueued
+	}
+	// A concurrent upload of the same file may have been recorded since the
+	// lookup above. It stored the same content under the same key, so its
+	// record is returned and the blob is left in place.
+	result := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "movie_id"}, {Name: "sha256"}},
+		DoNothing: true,
+	}).Create(&media)
+	if result.Error != nil {
+		// Keep the blob if a concurrent upload recorded it after all.
+		if s.db.Where("storage_key = ?", key).Limit(1).Find(&existing).RowsAffected == 0 {
+			s.store.Delete(ctx, key)
+		}
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		err := s.db.Where("movie_id = ? AND sha256 = ?", movieID, sum).First(&existing).Error
+		if err != nil {
+			return nil, false, err
+		}
+		return &existing, false, nil
+	}
+	if media.HLSStatus == models.HLSStatusQueued {
+		s.packager.Enqueue()
+	}
+
+	return &media, true, nil
+}
+
+// cleanFilename drops any directories from a client supplied file name.
+func cleanFilename(name string) string {
+	name = filepath.Base(filepath.ToSlash(name))
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+func (s *MediaService) sessionPath(id string) string {
+	return filepath.Join(s.stagingDir, "session-"+id)
+}
+
+func (s *MediaService) purgeExpiredSessions() error {
+	var expired []models.UploadSession
+	if err := s.db.Where("expires_at <= ?", time.Now()).Find(&expired).Error; err != nil {
+		return err
+	}
+
+	for _, session := range expired {
+		os.Remove(s.sessionPath(session.ID))
+		if err := s.db.Delete(&session).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
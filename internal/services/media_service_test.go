@@ -0,0 +1,116 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/mehmonov/movies-crud/config"
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/storage"
+)
+
+// webmHeader is enough of a WebM file to be sniffed as video/webm.
+var webmHeader = []byte("\x1a\x45\xdf\xa3\x9f\x42\x86\x81\x01webm")
+
+func testMediaService(t *testing.T) (*MediaService, *models.Movie) {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Storage.UploadDir = t.TempDir()
+	database := testDatabase(t, cfg)
+
+	store, err := storage.NewLocalBlobStore(cfg.Storage.UploadDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, err := NewMediaService(cfg, database, store, NewHLSService(cfg, database, store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	movie := &models.Movie{Title: "Heat", Year: 1995}
+	if err := database.Create(movie).Error; err != nil {
+		t.Fatal(err)
+	}
+	return service, movie
+}
+
+func TestUploadMediaStoresUnderFullDigest(t *testing.T) {
+	service, movie := testMediaService(t)
+	media, created, err := service.UploadMedia(context.Background(), movie.ID, "heat.webm", bytes.NewReader(webmHeader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || media.StorageKey != "movie_1/"+media.SHA256+".webm" || len(media.SHA256) != 64 {
+		t.Errorf("got key %q for digest %q, created %v", media.StorageKey, media.SHA256, created)
+	}
+}
+
+func TestUploadMediaRecordedConcurrently(t *testing.T) {
+	service, movie := testMediaService(t)
+
+	// Record the same file just before the upload does, as a concurrent
+	// upload that passed the duplicate lookup at the same time would.
+	var concurrent models.MediaFile
+	recorded := false
+	err := service.db.Callback().Create().Before("gorm:create").Register("test:concurrent_upload", func(tx *gorm.DB) {
+		media, ok := tx.Statement.Dest.(*models.MediaFile)
+		if !ok || recorded {
+			return
+		}
+		recorded = true
+		concurrent = *media
+		concurrent.Filename = "concurrent.webm"
+		tx.Session(&gorm.Session{NewDB: true}).Create(&concurrent)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	media, created, err := service.UploadMedia(context.Background(), movie.ID, "heat.webm", bytes.NewReader(webmHeader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || media.ID != concurrent.ID || media.Filename != "concurrent.webm" {
+		t.Errorf("got media %d %q, created %v; want the concurrent upload %d", media.ID, media.Filename, created, concurrent.ID)
+	}
+	if _, err := os.Stat(filepath.Join(service.stagingDir, "..", media.StorageKey)); err != nil {
+		t.Errorf("stored file: %v", err)
+	}
+}
+
+func TestAppendUploadChunk(t *testing.T) {
+	service, movie := testMediaService(t)
+	ctx := context.Background()
+	session, err := service.CreateUploadSession(movie.ID, &models.UploadSessionRequest{Filename: "heat.webm", Size: int64(len(webmHeader))})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := service.AppendUploadChunk(ctx, movie.ID, session.ID, 4, bytes.NewReader(webmHeader[4:])); !errors.Is(err, ErrUploadOffsetMismatch) {
+		t.Fatalf("chunk at the wrong offset: got %v, want ErrUploadOffsetMismatch", err)
+	}
+	session, media, err := service.AppendUploadChunk(ctx, movie.ID, session.ID, 0, bytes.NewReader(webmHeader[:4]))
+	if err != nil || media != nil || session.Offset != 4 {
+		t.Fatalf("first chunk: offset %v, media %v, err %v", session, media, err)
+	}
+	tooLong := strings.NewReader(string(webmHeader[4:]) + "x")
+	if _, _, err := service.AppendUploadChunk(ctx, movie.ID, session.ID, 4, tooLong); !errors.Is(err, ErrUploadChunkTooLarge) {
+		t.Fatalf("chunk past the size: got %v, want ErrUploadChunkTooLarge", err)
+	}
+	session, media, err = service.AppendUploadChunk(ctx, movie.ID, session.ID, 4, bytes.NewReader(webmHeader[4:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media == nil || media.Size != int64(len(webmHeader)) {
+		t.Fatalf("last chunk stored %+v, want the whole file", media)
+	}
+	if _, err := service.GetUploadSession(movie.ID, session.ID); !errors.Is(err, ErrUploadSessionNotFound) {
+		t.Errorf("session after the upload: got %v, want ErrUploadSessionNotFound", err)
+	}
+}
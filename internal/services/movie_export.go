@@ -0,0 +1,188 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+// exportBatchSize is the number of movies read, with their genres, per
+// query of an export.
+const exportBatchSize = 1000
+
+var ErrInvalidExportFormat = errors.New("export format must be csv, ndjson or json")
+
+// exportColumns is the header of a CSV export.
+var exportColumns = []string{
+	"id", "title", "director", "year", "plot", "genre_ids", "genres",
+	"average_rating", "vote_count", "created_at", "updated_at",
+}
+
+// ExportMovies writes the movies matching the filters of query to w in
+// format, ordered by ID, and returns how many were written. Movies are read
+// in batches so that memory use does not grow with the catalog; w is
+// written to after every batch. Paging and sorting in query are ignored.
+func (s *MovieService) ExportMovies(ctx context.Context, w io.Writer, format string, query *models.MovieListQuery) (int, error) {
+	var encoder movieEncoder
+	switch format {
+	case models.CatalogFormatCSV:
+		encoder = newCSVMovieEncoder(w)
+	case models.CatalogFormatNDJSON:
+		encoder = newJSONMovieEncoder(w, false)
+	case models.CatalogFormatJSON:
+		encoder = newJSONMovieEncoder(w, true)
+	default:
+		return 0, ErrInvalidExportFormat
+	}
+
+	if err := encoder.begin(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err := s.movies.Each(ctx, query, exportBatchSize, func(movies []models.Movie) error {
+		for i := range movies {
+			if err := encoder.write(exportRow(&movies[i])); err != nil {
+				return err
+			}
+		}
+		count += len(movies)
+		return encoder.flush()
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, encoder.end()
+}
+
+func exportRow(movie *models.Movie) *models.MovieExportRow {
+	row := &models.MovieExportRow{
+		ID:        movie.ID,
+		Title:     movie.Title,
+		Director:  movie.Director,
+		Year:      movie.Year,
+		Plot:      movie.Plot,
+		GenreIDs:  make([]uint, 0, len(movie.Genres)),
+		Genres:    make([]string, 0, len(movie.Genres)),
+		Rating:    movie.Rating,
+		Votes:     movie.Votes,
+		CreatedAt: movie.CreatedAt,
+		UpdatedAt: movie.UpdatedAt,
+	}
+	for _, genre := range movie.Genres {
+		row.GenreIDs = append(row.GenreIDs, genre.ID)
+		row.Genres = append(row.Genres, genre.Name)
+	}
+	return row
+}
+
+// movieEncoder writes the rows of an export in one format.
+type movieEncoder interface {
+	begin() error
+	write(row *models.MovieExportRow) error
+	// flush writes out the buffered rows.
+	flush() error
+	end() error
+}
+
+type csvMovieEncoder struct {
+	writer *csv.Writer
+}
+
+func newCSVMovieEncoder(w io.Writer) *csvMovieEncoder {
+	return &csvMovieEncoder{writer: csv.NewWriter(w)}
+}
+
+func (e *csvMovieEncoder) begin() error {
+	return e.writer.Write(exportColumns)
+}
+
+func (e *csvMovieEncoder) write(row *models.MovieExportRow) error {
+	genreIDs := make([]string, len(row.GenreIDs))
+	for i, id := range row.GenreIDs {
+		genreIDs[i] = strconv.FormatUint(uint64(id), 10)
+	}
+
+	return e.writer.Write([]string{
+		strconv.FormatUint(uint64(row.ID), 10),
+		row.Title,
+		row.Director,
+		strconv.Itoa(row.Year),
+		row.Plot,
+		strings.Join(genreIDs, "|"),
+		strings.Join(row.Genres, "|"),
+		strconv.FormatFloat(row.Rating, 'f', -1, 64),
+		strconv.Itoa(row.Votes),
+		row.CreatedAt.UTC().Format(time.RFC3339),
+		row.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (e *csvMovieEncoder) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvMovieEncoder) end() error {
+	return e.flush()
+}
+
+// jsonMovieEncoder writes one JSON object per line, wrapped in an array
+// when array is set.
+type jsonMovieEncoder struct {
+	writer *bufio.Writer
+	array  bool
+	rows   int
+}
+
+func newJSONMovieEncoder(w io.Writer, array bool) *jsonMovieEncoder {
+	return &jsonMovieEncoder{writer: bufio.NewWriter(w), array: array}
+}
+
+func (e *jsonMovieEncoder) begin() error {
+	if e.array {
+		_, err := e.writer.WriteString("[")
+		return err
+	}
+	return nil
+}
+
+func (e *jsonMovieEncoder) write(row *models.MovieExportRow) error {
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+
+	if e.array {
+		if e.rows > 0 {
+			e.writer.WriteString(",")
+		}
+		e.writer.WriteString("\n")
+	}
+	e.rows++
+	e.writer.Write(data)
+	if !e.array {
+		e.writer.WriteString("\n")
+	}
+	return nil
+}
+
+func (e *jsonMovieEncoder) flush() error {
+	return e.writer.Flush()
+}
+
+func (e *jsonMovieEncoder) end() error {
+	if e.array {
+		e.writer.WriteString("\n]\n")
+	}
+	return e.writer.Flush()
+}
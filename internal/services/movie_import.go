@@ -0,0 +1,551 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+const (
+	DefaultImportBatchSize = 500
+	MaxImportBatchSize     = 5000
+
+	// maxImportErrors bounds the errors listed in a report.
+	maxImportErrors = 1000
+	// maxImportLine bounds the length of an NDJSON line.
+	maxImportLine = 1 << 20
+)
+
+var (
+	ErrInvalidImportFormat = errors.New("import format must be csv or ndjson")
+	ErrInvalidImportHeader = errors.New("invalid CSV header")
+)
+
+// importColumns are the CSV columns an import understands. Genre IDs are
+// separated by "|"; leaving the genre_ids column out keeps the genres of
+// existing movies. The columns set to false are written by an export and
+// ignored, so that an export can be imported again.
+var importColumns = map[string]bool{
+	"title":          true,
+	"director":       true,
+	"year":           true,
+	"plot":           true,
+	"genre_ids":      true,
+	"id":             false,
+	"genres":         false,
+	"average_rating": false,
+	"vote_count":     false,
+	"created_at":     false,
+	"updated_at":     false,
+}
+
+// errDryRun rolls back the transaction of a batch in a dry run.
+var errDryRun = errors.New("dry run")
+
+// importRow is a parsed row with the line it was read from.
+type importRow struct {
+	line int
+	req  models.CreateMovieRequest
+}
+
+// rowError is a problem with a single row that does not stop the import.
+type rowError struct {
+	field   string
+	message string
+}
+
+func (e *rowError) Error() string {
+	return e.message
+}
+
+// movieImport is the state of one running import.
+type movieImport struct {
+	db        *gorm.DB
+	userID    uint
+	batchSize int
+	report    *models.MovieImportReport
+	batch     []importRow
+	// lower folds titles as the LOWER function of the database does.
+	lower func(string) string
+	// seen holds the keys of the movies imported so far, so that a dry run
+	// reports a title repeated across batches as an update.
+	seen map[string]bool
+}
+
+// ImportMovies reads movies in CSV or NDJSON from r and creates them, or
+// updates the movie with the same title (ignoring case) and year. Every row
+// is validated like a CreateMovieRequest; invalid rows are reported and
+// skipped. Rows are written in transactions of batchSize rows, so a failed
+// import keeps the batches before it. A dry run validates and matches every
+// row but rolls each batch back. Every created or changed movie gets a
+// revision by userID.
+func (s *MovieService) ImportMovies(ctx context.Context, r io.Reader, format string, dryRun bool, batchSize int, userID uint) (*models.MovieImportReport, error) {
+	if batchSize < 1 {
+		batchSize = DefaultImportBatchSize
+	}
+	if batchSize > MaxImportBatchSize {
+		batchSize = MaxImportBatchSize
+	}
+
+	imp := &movieImport{
+		db:        s.db.WithContext(ctx),
+		userID:    userID,
+		batchSize: batchSize,
+		report:    &models.MovieImportReport{DryRun: dryRun, Errors: []models.MovieImportError{}},
+		lower:     sqlLower(s.db),
+		seen:      make(map[string]bool),
+	}
+
+	var err error
+	switch format {
+	case models.CatalogFormatCSV:
+		err = imp.readCSV(r)
+	case models.CatalogFormatNDJSON:
+		err = imp.readNDJSON(r)
+	default:
+		return nil, ErrInvalidImportFormat
+	}
+	if err == nil {
+		err = imp.flush()
+	}
+	return imp.report, err
+}
+
+func (imp *movieImport) readCSV(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidImportHeader, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := importColumns[name]; !ok {
+			return fmt.Errorf("%w: unknown column %q", ErrInvalidImportHeader, name)
+		}
+		if _, ok := columns[name]; ok {
+			return fmt.Errorf("%w: duplicate column %q", ErrInvalidImportHeader, name)
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"title", "director", "year"} {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("%w: missing column %q", ErrInvalidImportHeader, name)
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			imp.fail(parseErr.StartLine, &rowError{message: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		line, _ := reader.FieldPos(0)
+		req, err := csvMovie(record, columns)
+		if err != nil {
+			imp.fail(line, err)
+			continue
+		}
+		if err := imp.add(importRow{line: line, req: *req}); err != nil {
+			return err
+		}
+	}
+}
+
+func csvMovie(record []string, columns map[string]int) (*models.CreateMovieRequest, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	req := &models.CreateMovieRequest{
+		Title:    field("title"),
+		Director: field("director"),
+		Plot:     field("plot"),
+	}
+	if year := field("year"); year != "" {
+		n, err := strconv.Atoi(year)
+		if err != nil {
+			return nil, &rowError{field: "year", message: fmt.Sprintf("year %q is not a number", year)}
+		}
+		req.Year = n
+	}
+	if _, ok := columns["genre_ids"]; ok {
+		req.GenreIDs = []uint{}
+		for _, id := range strings.Split(field("genre_ids"), "|") {
+			if id = strings.TrimSpace(id); id == "" {
+				continue
+			}
+			n, err := strconv.ParseUint(id, 10, 32)
+			if err != nil {
+				return nil, &rowError{field: "genre_ids", message: fmt.Sprintf("genre ID %q is not a number", id)}
+			}
+			req.GenreIDs = append(req.GenreIDs, uint(n))
+		}
+	}
+	return req, nil
+}
+
+func (imp *movieImport) readNDJSON(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLine)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := scanner.Bytes()
+		if len(strings.TrimSpace(string(data))) == 0 {
+			continue
+		}
+
+		var req models.CreateMovieRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			imp.fail(line, jsonRowError(err))
+			continue
+		}
+		if err := imp.add(importRow{line: line, req: req}); err != nil {
+			return err
+		}
+	}
+	if errors.Is(scanner.Err(), bufio.ErrTooLong) {
+		return fmt.Errorf("line %d is longer than %d bytes", line+1, maxImportLine)
+	}
+	return scanner.Err()
+}
+
+// jsonRowError describes why a line could not be decoded as a movie.
+func jsonRowError(err error) *rowError {
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Field == "" {
+		return &rowError{message: "invalid JSON: " + err.Error()}
+	}
+
+	field := strings.Split(typeErr.Field, ".")[0]
+	expected := "a string"
+	switch field {
+	case "year":
+		expected = "a number"
+	case "genre_ids":
+		expected = "a list of genre IDs"
+	}
+	return &rowError{field: field, message: fmt.Sprintf("%s must be %s", field, expected)}
+}
+
+// add validates row and queues it, writing the batch once it is full.
+func (imp *movieImport) add(row importRow) error {
+	row.req.Title = strings.TrimSpace(row.req.Title)
+	row.req.Director = strings.TrimSpace(row.req.Director)
+	if err := validateMovieFields(&row.req); err != nil {
+		imp.fail(row.line, err)
+		return nil
+	}
+
+	imp.batch = append(imp.batch, row)
+	if len(imp.batch) >= imp.batchSize {
+		return imp.flush()
+	}
+	return nil
+}
+
+// validateMovieFields applies the binding rules of req, a pointer to a
+// request struct, and describes the first broken rule as a *rowError.
+func validateMovieFields(req interface{}) error {
+	err := binding.Validator.ValidateStruct(req)
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) || len(fieldErrors) == 0 {
+		return err
+	}
+
+	fe := fieldErrors[0]
+	name := fe.Field()
+	if field, ok := reflect.TypeOf(req).Elem().FieldByName(fe.StructField()); ok {
+		name = strings.Split(field.Tag.Get("json"), ",")[0]
+	}
+
+	var message string
+	switch fe.Tag() {
+	case "required":
+		message = name + " is required"
+	case "min":
+		message = fmt.Sprintf("%s must be at least %s", name, fe.Param())
+	case "max":
+		message = fmt.Sprintf("%s must be at most %s", name, fe.Param())
+	default:
+		message = fmt.Sprintf("%s is invalid", name)
+	}
+	return &rowError{field: name, message: message}
+}
+
+func (imp *movieImport) fail(line int, err error) {
+	imp.report.Rows++
+	imp.report.Failed++
+	if len(imp.report.Errors) >= maxImportErrors {
+		imp.report.ErrorsTruncated = true
+		return
+	}
+
+	importErr := models.MovieImportError{Line: line, Message: err.Error()}
+	var rowErr *rowError
+	if errors.As(err, &rowErr) {
+		importErr.Field = rowErr.field
+	}
+	imp.report.Errors = append(imp.report.Errors, importErr)
+}
+
+// flush writes the queued rows in one transaction. Rows whose genres do not
+// exist are reported; if the transaction fails, every row in it is.
+func (imp *movieImport) flush() error {
+	rows := imp.batch
+	imp.batch = nil
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var created, updated int
+	var failed []importRow
+	var seen []string
+	err := imp.db.Transaction(func(tx *gorm.DB) error {
+		created, updated, failed, seen = 0, 0, nil, nil
+
+		rows, failed = imp.checkGenres(tx, rows)
+		movies, err := lockImportMatches(tx, rows, imp.lower)
+		if err != nil {
+			return err
+		}
+		var matchedIDs []uint
+		for _, movie := range movies {
+			matchedIDs = append(matchedIDs, movie.ID)
+		}
+		before, err := snapshotMovies(tx, matchedIDs)
+		if err != nil {
+			return err
+		}
+
+		// Repeated rows update the movie created or matched by the first.
+		type change struct {
+			movie     *models.Movie
+			genreIDs  []uint
+			directors bool
+		}
+		changes := make(map[string]*change)
+		var order []string
+		for _, row := range rows {
+			key := importKey(imp.lower, row.req.Title, row.req.Year)
+			ch, ok := changes[key]
+			if !ok {
+				movie := movies[key]
+				if movie == nil {
+					movie = &models.Movie{}
+				}
+				ch = &change{movie: movie}
+				changes[key] = ch
+				order = append(order, key)
+			}
+			if ch.movie.ID != 0 || imp.seen[key] || ok {
+				updated++
+			} else {
+				created++
+			}
+
+			if ch.movie.Director != row.req.Director {
+				ch.directors = true
+			}
+			ch.movie.Title = row.req.Title
+			ch.movie.Director = row.req.Director
+			ch.movie.Year = row.req.Year
+			ch.movie.Plot = row.req.Plot
+			if row.req.GenreIDs != nil {
+				ch.genreIDs = row.req.GenreIDs
+			}
+		}
+
+		for _, key := range order {
+			ch := changes[key]
+			if ch.movie.ID != 0 {
+				// The movie is locked, so this is its next version.
+				ch.movie.Version++
+			}
+			if err := tx.Omit(clause.Associations).Save(ch.movie).Error; err != nil {
+				return err
+			}
+			if ch.directors {
+				if err := setMovieDirectors(tx, ch.movie.ID, ch.movie.Director); err != nil {
+					return err
+				}
+			}
+			if ch.genreIDs != nil {
+				genres, err := findGenres(tx, ch.genreIDs)
+				if err != nil {
+					return err
+				}
+				if err := tx.Model(ch.movie).Association("Genres").Replace(genres); err != nil {
+					return err
+				}
+			}
+			seen = append(seen, key)
+		}
+
+		ids := make([]uint, 0, len(order))
+		for _, key := range order {
+			ids = append(ids, changes[key].movie.ID)
+		}
+		after, err := snapshotMovies(tx, ids)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			revision := models.MovieRevision{MovieID: id, Action: models.RevisionActionUpdate}
+			if before[id] == nil {
+				revision.Action = models.RevisionActionCreate
+			}
+			if err := recordRevision(tx, &revision, imp.userID, before[id], after[id]); err != nil {
+				return err
+			}
+		}
+
+		if imp.report.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		if ctxErr := imp.db.Statement.Context.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		for _, row := range rows {
+			imp.fail(row.line, fmt.Errorf("batch was not imported: %v", err))
+		}
+		rows = nil
+		created, updated = 0, 0
+	}
+
+	for _, row := range failed {
+		imp.fail(row.line, &rowError{field: "genre_ids", message: ErrGenreNotFound.Error()})
+	}
+	for _, key := range seen {
+		imp.seen[key] = true
+	}
+	imp.report.Rows += len(rows)
+	imp.report.Created += created
+	imp.report.Updated += updated
+	return nil
+}
+
+// checkGenres splits rows into those whose genres all exist and those
+// referring to a missing genre.
+func (imp *movieImport) checkGenres(tx *gorm.DB, rows []importRow) ([]importRow, []importRow) {
+	var ids []uint
+	for _, row := range rows {
+		ids = append(ids, row.req.GenreIDs...)
+	}
+	if len(ids) == 0 {
+		return rows, nil
+	}
+
+	var existing []uint
+	if err := tx.Model(&models.Genre{}).Where("id IN ?", ids).Pluck("id", &existing).Error; err != nil {
+		// Leave the rows in; findGenres reports the error for the batch.
+		return rows, nil
+	}
+	known := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		known[id] = true
+	}
+
+	var valid, invalid []importRow
+	for _, row := range rows {
+		ok := true
+		for _, id := range row.req.GenreIDs {
+			ok = ok && known[id]
+		}
+		if ok {
+			valid = append(valid, row)
+		} else {
+			invalid = append(invalid, row)
+		}
+	}
+	return valid, invalid
+}
+
+// lockImportMatches loads and locks the existing movies matching rows, keyed
+// by importKey. Locking keeps a concurrent review from having its rating
+// overwritten, as in UpdateMovie. lower must fold titles as LOWER does in
+// the query, or a movie matched there would be missed in the map.
+func lockImportMatches(tx *gorm.DB, rows []importRow, lower func(string) string) (map[string]*models.Movie, error) {
+	matches := make(map[string]*models.Movie)
+	if len(rows) == 0 {
+		return matches, nil
+	}
+
+	keys := make([][]interface{}, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, []interface{}{lower(row.req.Title), row.req.Year})
+	}
+
+	var movies []models.Movie
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("(LOWER(title), year) IN ?", keys).
+		Order("id ASC").
+		Find(&movies).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range movies {
+		key := importKey(lower, movies[i].Title, movies[i].Year)
+		// When the catalog already has duplicates, update the oldest.
+		if _, ok := matches[key]; !ok {
+			matches[key] = &movies[i]
+		}
+	}
+	return matches, nil
+}
+
+func importKey(lower func(string) string, title string, year int) string {
+	return strconv.Itoa(year) + "\x00" + lower(title)
+}
+
+// sqlLower returns how the LOWER function of db folds a string. PostgreSQL
+// lowercases every letter, SQLite only the ASCII ones, so "Élan" and "élan"
+// are different titles there.
+func sqlLower(db *gorm.DB) func(string) string {
+	if db.Dialector.Name() != "sqlite" {
+		return strings.ToLower
+	}
+	return func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if 'A' <= r && r <= 'Z' {
+				return r + 'a' - 'A'
+			}
+			return r
+		}, s)
+	}
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+func TestImportMatchesExistingTitles(t *testing.T) {
+	service, user := testMovieService(t)
+	for _, title := range []string{"Élan", "Heat"} {
+		if _, err := service.CreateMovie(&models.CreateMovieRequest{Title: title, Director: "Someone", Year: 1995}, user.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		title   string
+		created bool
+	}{
+		{"Élan", false},
+		{"HEAT", false},
+		// SQLite only folds ASCII letters, so this is a new title.
+		{"élan", true},
+	}
+	for _, test := range tests {
+		csv := "title,director,year\n" + test.title + ",Another,1995\n"
+		report, err := service.ImportMovies(context.Background(), strings.NewReader(csv), models.CatalogFormatCSV, false, 0, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if created := report.Created == 1; created != test.created || report.Created+report.Updated != 1 {
+			t.Errorf("import of %q: report %+v, want created %v", test.title, report, test.created)
+		}
+	}
+
+	var count int64
+	if err := service.db.Model(&models.Movie{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("%d movies after the imports, want 3", count)
+	}
+}
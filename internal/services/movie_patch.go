@@ -0,0 +1,114 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/pkg/jsonpatch"
+)
+
+var (
+	ErrUnsupportedPatchType = errors.New("unsupported patch type")
+	// ErrInvalidMoviePatch is returned when the patched movie breaks the
+	// rules of a movie, e.g. it has no title.
+	ErrInvalidMoviePatch = errors.New("invalid movie")
+)
+
+// PatchMovie applies patch, a JSON Merge Patch or a JSON Patch as told by
+// patchType, to the editable fields of a movie (see
+// models.MoviePatchDocument) and saves the result if it is a valid movie.
+// The whole patch is applied in one transaction, or not at all. Version and
+// userID are as in UpdateMovie.
+func (s *MovieService) PatchMovie(id uint, version int, patchType string, patch []byte, userID uint) error {
+	var apply func(doc, patch []byte) ([]byte, error)
+	switch patchType {
+	case models.PatchTypeMerge:
+		apply = jsonpatch.MergePatch
+	case models.PatchTypeJSON:
+		apply = jsonpatch.Apply
+	default:
+		return ErrUnsupportedPatchType
+	}
+
+	return s.transaction(func(ctx context.Context, tx *gorm.DB) error {
+		movie, err := s.movies.GetForUpdate(ctx, id, version)
+		if err != nil {
+			return movieNotFound(err)
+		}
+		before, err := snapshotMovies(tx, []uint{movie.ID})
+		if err != nil {
+			return err
+		}
+
+		doc, err := json.Marshal(before[movie.ID])
+		if err != nil {
+			return err
+		}
+		if doc, err = apply(doc, patch); err != nil {
+			return err
+		}
+		target, err := decodeMoviePatchDocument(doc)
+		if err != nil {
+			return err
+		}
+
+		movie.Title = target.Title
+		movie.Year = target.Year
+		movie.Plot = target.Plot
+		if err := s.movies.Update(ctx, movie); err != nil {
+			return err
+		}
+		if target.Director != before[movie.ID].Director {
+			if err := setMovieDirectors(tx, movie.ID, target.Director); err != nil {
+				return err
+			}
+		}
+		genres, err := findGenres(tx, target.GenreIDs)
+		if err != nil {
+			return err
+		}
+		if err := s.movies.SetGenres(ctx, movie.ID, genres); err != nil {
+			return err
+		}
+
+		after, err := snapshotMovies(tx, []uint{movie.ID})
+		if err != nil {
+			return err
+		}
+		revision := models.MovieRevision{MovieID: movie.ID, Action: models.RevisionActionUpdate}
+		return recordRevision(tx, &revision, userID, before[movie.ID], after[movie.ID])
+	})
+}
+
+// decodeMoviePatchDocument reads and validates a patched movie.
+func decodeMoviePatchDocument(doc []byte) (*models.MoviePatchDocument, error) {
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	var target models.MoviePatchDocument
+	if err := decoder.Decode(&target); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &typeErr) && typeErr.Field == "":
+			return nil, fmt.Errorf("%w: the movie must be an object", ErrInvalidMoviePatch)
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			name := strings.TrimPrefix(err.Error(), "json: unknown field ")
+			return nil, fmt.Errorf("%w: %s cannot be changed", ErrInvalidMoviePatch, name)
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrInvalidMoviePatch, jsonRowError(err).message)
+		}
+	}
+
+	target.Title = strings.TrimSpace(target.Title)
+	target.Director = strings.TrimSpace(target.Director)
+	if err := validateMovieFields(&target); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMoviePatch, err)
+	}
+	return &target, nil
+}
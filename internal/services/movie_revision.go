@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// MissingGenresError is returned by RevertMovie when genres of the revision
+// have been deleted since. It matches ErrGenreNotFound.
+type MissingGenresError struct {
+	IDs []uint
+}
+
+func (e *MissingGenresError) Error() string {
+	ids := make([]string, len(e.IDs))
+	for i, id := range e.IDs {
+		ids[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	if len(ids) == 1 {
+		return "genre " + ids[0] + " of the revision no longer exists"
+	}
+	return "genres " + strings.Join(ids, ", ") + " of the revision no longer exist"
+}
+
+func (e *MissingGenresError) Is(target error) bool {
+	return target == ErrGenreNotFound
+}
+
+// GetMovieRevisions returns the revisions of a movie, newest first. The
+// history of a deleted movie is kept and can still be read.
+func (s *MovieService) GetMovieRevisions(movieID uint) ([]models.MovieRevision, error) {
+	var count int64
+	if err := s.db.Unscoped().Model(&models.Movie{}).Where("id = ?", movieID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrMovieNotFound
+	}
+
+	revisions := []models.MovieRevision{}
+	err := s.db.Preload("User").
+		Where("movie_id = ?", movieID).
+		Order("id DESC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+// RevertMovie restores the fields a movie had after revisionID, and records
+// that as a new revision by userID. Unless version is 0, the revert only
+// applies to that version of the movie and fails with ErrMovieVersionMismatch
+// otherwise. If genres of the revision have been deleted since, it fails with
+// a *MissingGenresError, unless dropMissingGenres is set and they are left
+// out.
+func (s *MovieService) RevertMovie(movieID, revisionID uint, version int, dropMissingGenres bool, userID uint) (*models.Movie, error) {
+	var movie *models.Movie
+	err := s.transaction(func(ctx context.Context, tx *gorm.DB) error {
+		var revision models.MovieRevision
+		err := tx.Where("id = ? AND movie_id = ?", revisionID, movieID).First(&revision).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRevisionNotFound
+			}
+			return err
+		}
+
+		current, err := s.movies.GetForUpdate(ctx, movieID, version)
+		if err != nil {
+			return movieNotFound(err)
+		}
+		before, err := snapshotMovies(tx, []uint{movieID})
+		if err != nil {
+			return err
+		}
+
+		target := revision.Snapshot
+		genres := []models.Genre{}
+		if len(target.GenreIDs) > 0 {
+			if err := tx.Where("id IN ?", target.GenreIDs).Order("id").Find(&genres).Error; err != nil {
+				return err
+			}
+		}
+		if missing := missingGenreIDs(target.GenreIDs, genres); len(missing) > 0 && !dropMissingGenres {
+			return &MissingGenresError{IDs: missing}
+		}
+
+		current.Title = target.Title
+		current.Year = target.Year
+		current.Plot = target.Plot
+		if err := s.movies.Update(ctx, current); err != nil {
+			return err
+		}
+		if target.Director != before[movieID].Director {
+			if err := setMovieDirectors(tx, movieID, target.Director); err != nil {
+				return err
+			}
+		}
+		if err := s.movies.SetGenres(ctx, movieID, genres); err != nil {
+			return err
+		}
+
+		after, err := snapshotMovies(tx, []uint{movieID})
+		if err != nil {
+			return err
+		}
+		err = recordRevision(tx, &models.MovieRevision{
+			MovieID:      movieID,
+			Action:       models.RevisionActionRevert,
+			RevertedFrom: &revision.ID,
+		}, userID, before[movieID], after[movieID])
+		if err != nil {
+			return err
+		}
+
+		movie, err = s.movies.Get(ctx, movieID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movie, nil
+}
+
+// missingGenreIDs returns the IDs in ids, sorted, that none of genres has.
+func missingGenreIDs(ids []uint, genres []models.Genre) []uint {
+	found := make(map[uint]bool, len(genres))
+	for _, genre := range genres {
+		found[genre.ID] = true
+	}
+	var missing []uint
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+			found[id] = true
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i] < missing[j] })
+	return missing
+}
+
+// snapshotMovies reads the editable fields of the given movies, keyed by ID.
+func snapshotMovies(tx *gorm.DB, ids []uint) (map[uint]*models.MovieSnapshot, error) {
+	snapshots := make(map[uint]*models.MovieSnapshot, len(ids))
+	if len(ids) == 0 {
+		return snapshots, nil
+	}
+
+	var movies []models.Movie
+	if err := tx.Unscoped().Where("id IN ?", ids).Find(&movies).Error; err != nil {
+		return nil, err
+	}
+	for _, movie := range movies {
+		snapshots[movie.ID] = &models.MovieSnapshot{
+			Title:    movie.Title,
+			Director: movie.Director,
+			Year:     movie.Year,
+			Plot:     movie.Plot,
+			GenreIDs: []uint{},
+		}
+	}
+
+	var links []struct {
+		MovieID uint
+		GenreID uint
+	}
+	err := tx.Table("movie_genres").
+		Select("movie_id, genre_id").
+		Where("movie_id IN ?", ids).
+		Order("genre_id ASC").
+		Scan(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		if snapshot, ok := snapshots[link.MovieID]; ok {
+			snapshot.GenreIDs = append(snapshot.GenreIDs, link.GenreID)
+		}
+	}
+	return snapshots, nil
+}
+
+// recordRevision fills in revision from the movie before and after the
+// change, either of which is nil when the movie was created or deleted, and
+// saves it. Updates that change nothing are not recorded.
+func recordRevision(tx *gorm.DB, revision *models.MovieRevision, userID uint, before, after *models.MovieSnapshot) error {
+	revision.Changes = diffSnapshots(before, after)
+	if len(revision.Changes) == 0 && before != nil && after != nil {
+		return nil
+	}
+
+	if userID != 0 {
+		revision.UserID = &userID
+	}
+	if after != nil {
+		revision.Snapshot = *after
+	} else if before != nil {
+		revision.Snapshot = *before
+	}
+	return tx.Omit(clause.Associations).Create(revision).Error
+}
+
+// diffSnapshots lists the fields that differ between before and after.
+func diffSnapshots(before, after *models.MovieSnapshot) map[string]models.FieldChange {
+	fields := func(snapshot *models.MovieSnapshot) map[string]interface{} {
+		if snapshot == nil {
+			return map[string]interface{}{}
+		}
+		genreIDs := append([]uint{}, snapshot.GenreIDs...)
+		sort.Slice(genreIDs, func(i, j int) bool { return genreIDs[i] < genreIDs[j] })
+		return map[string]interface{}{
+			"title":     snapshot.Title,
+			"director":  snapshot.Director,
+			"year":      snapshot.Year,
+			"plot":      snapshot.Plot,
+			"genre_ids": genreIDs,
+		}
+	}
+
+	from, to := fields(before), fields(after)
+	changes := map[string]models.FieldChange{}
+	for _, name := range []string{"title", "director", "year", "plot", "genre_ids"} {
+		if !reflect.DeepEqual(from[name], to[name]) {
+			changes[name] = models.FieldChange{From: from[name], To: to[name]}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+// MovieSearcher finds movies matching a free-text query over their title,
+// director and plot.
+type MovieSearcher interface {
+	Search(query string, page, limit int) ([]models.MovieSearchResult, error)
+}
+
+const (
+	// searchHighlightStart and searchHighlightStop mark matched words while
+	// snippets are built. They are private use characters, which titles and
+	// plots do not contain, so that searchHighlightHTML can escape the text
+	// before turning them into <mark> tags.
+	searchHighlightStart = "\ue000"
+	searchHighlightStop  = "\ue001"
+
+	// searchSimilarityThreshold is the minimum pg_trgm word similarity for a
+	// title or director to count as a fuzzy match.
+	searchSimilarityThreshold = 0.3
+)
+
+// PostgresMovieSearcher searches the movies table using the search_vector
+// tsvector column for ranked full-text matches and pg_trgm for typos.
+type PostgresMovieSearcher struct {
+	db *gorm.DB
+}
+
+func NewPostgresMovieSearcher(db *gorm.DB) *PostgresMovieSearcher {
+	return &PostgresMovieSearcher{db: db}
+}
+
+type movieSearchRow struct {
+	models.Movie
+	Rank              float64
+	TitleHighlight    string
+	DirectorHighlight string
+	PlotHighlight     string
+}
+
+const movieSearchSQL = `
+SELECT movies.*,
+	ts_rank(search_vector, query) + GREATEST(word_similarity(@q, title), word_similarity(@q, director)) AS rank,
+	ts_headline('english', title, query, @options || ', HighlightAll=true') AS title_highlight,
+	ts_headline('english', director, query, @options || ', HighlightAll=true') AS director_highlight,
+	ts_headline('english', COALESCE(plot, ''), query, @options || ', MaxFragments=2') AS plot_highlight
+FROM movies, to_tsquery('english', @tsquery) AS query
+WHERE movies.deleted_at IS NULL
+	AND (search_vector @@ query
+		OR word_similarity(@q, title) > @threshold
+		OR word_similarity(@q, director) > @threshold)
+ORDER BY rank DESC, movies.id ASC
+LIMIT @limit OFFSET @offset`
+
+func (s *PostgresMovieSearcher) Search(query string, page, limit int) ([]models.MovieSearchResult, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return []models.MovieSearchResult{}, nil
+	}
+	plain := strings.Join(terms, " ")
+
+	// Every term must match, but only as a prefix so that partially typed
+	// words still find results.
+	for i := range terms {
+		terms[i] = terms[i] + ":*"
+	}
+
+	var rows []movieSearchRow
+	err := s.db.Raw(movieSearchSQL, map[string]interface{}{
+		"q":         plain,
+		"tsquery":   strings.Join(terms, " & "),
+		"options":   `StartSel="` + searchHighlightStart + `", StopSel="` + searchHighlightStop + `"`,
+		"threshold": searchSimilarityThreshold,
+		"limit":     limit,
+		"offset":    (page - 1) * limit,
+	}).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.MovieSearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, models.MovieSearchResult{
+			Movie: row.Movie,
+			Rank:  row.Rank,
+			Highlight: models.MovieSearchHighlight{
+				Title:    searchHighlightHTML(row.TitleHighlight),
+				Director: searchHighlightHTML(row.DirectorHighlight),
+				Plot:     searchHighlightHTML(row.PlotHighlight),
+			},
+		})
+	}
+	return results, nil
+}
+
+// searchHighlightHTML turns a snippet with highlight markers into HTML: the
+// text is escaped and the matched words are wrapped in <mark> tags, so that
+// clients can render it as is.
+func searchHighlightHTML(snippet string) string {
+	return strings.NewReplacer(searchHighlightStart, "<mark>", searchHighlightStop, "</mark>").
+		Replace(html.EscapeString(snippet))
+}
+
+// searchTerms splits query into lower-cased words, dropping punctuation so
+// the result is always safe to embed in a tsquery.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), isSearchSeparator)
+}
+
+func isSearchSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+// MemoryMovieSearcher is a MovieSearcher over an in-memory slice of movies.
+// It mirrors the behaviour of PostgresMovieSearcher closely enough for tests:
+// every query term must match a word by prefix, or by a single-character
+// typo for longer words, and title matches outrank director and plot ones.
+type MemoryMovieSearcher struct {
+	mu     sync.RWMutex
+	movies []models.Movie
+}
+
+func NewMemoryMovieSearcher(movies []models.Movie) *MemoryMovieSearcher {
+	return &MemoryMovieSearcher{movies: movies}
+}
+
+// Add makes movie searchable.
+func (s *MemoryMovieSearcher) Add(movie models.Movie) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.movies = append(s.movies, movie)
+}
+
+func (s *MemoryMovieSearcher) Search(query string, page, limit int) ([]models.MovieSearchResult, error) {
+	terms := searchTerms(query)
+	results := []models.MovieSearchResult{}
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	s.mu.RLock()
+	for _, movie := range s.movies {
+		if movie.DeletedAt.Valid {
+			continue
+		}
+
+		fields := []struct {
+			text   string
+			weight float64
+		}{
+			{movie.Title, 1.0},
+			{movie.Director, 0.4},
+			{movie.Plot, 0.2},
+		}
+
+		var rank float64
+		matched := true
+		for _, term := range terms {
+			var best float64
+			for _, field := range fields {
+				if score := memoryTermScore(term, field.text) * field.weight; score > best {
+					best = score
+				}
+			}
+			if best == 0 {
+				matched = false
+				break
+			}
+			rank += best
+		}
+		if !matched {
+			continue
+		}
+
+		results = append(results, models.MovieSearchResult{
+			Movie: movie,
+			Rank:  rank,
+			Highlight: models.MovieSearchHighlight{
+				Title:    memoryHighlight(movie.Title, terms),
+				Director: memoryHighlight(movie.Director, terms),
+				Plot:     memoryHighlight(movie.Plot, terms),
+			},
+		})
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	start := (page - 1) * limit
+	if start >= len(results) {
+		return []models.MovieSearchResult{}, nil
+	}
+	end := start + limit
+	if end > len(results) {
+		end = len(results)
+	}
+	return results[start:end], nil
+}
+
+// ScanMovieSearcher is a MovieSearcher that reads every movie from the
+// database and matches them as MemoryMovieSearcher does. It needs none of
+// the PostgreSQL search indexes, so it also works on SQLite, but reads the
+// whole catalog for each search; it is meant for development and tests.
+type ScanMovieSearcher struct {
+	db *gorm.DB
+}
+
+func NewScanMovieSearcher(db *gorm.DB) *ScanMovieSearcher {
+	return &ScanMovieSearcher{db: db}
+}
+
+func (s *ScanMovieSearcher) Search(query string, page, limit int) ([]models.MovieSearchResult, error) {
+	var movies []models.Movie
+	if err := s.db.Order("id").Find(&movies).Error; err != nil {
+		return nil, err
+	}
+	return NewMemoryMovieSearcher(movies).Search(query, page, limit)
+}
+
+// memoryTermScore reports how well term matches any word of text: 1 for a
+// prefix match, 0.5 for a word within one edit of term, and 0 otherwise.
+func memoryTermScore(term, text string) float64 {
+	var best float64
+	for _, word := range searchTerms(text) {
+		if strings.HasPrefix(word, term) {
+			return 1
+		}
+		if len(term) >= 4 && editDistanceAtMostOne(term, word) {
+			best = 0.5
+		}
+	}
+	return best
+}
+
+// memoryHighlight escapes text as HTML and wraps every word of it matched
+// by one of terms in <mark> tags.
+func memoryHighlight(text string, terms []string) string {
+	var b strings.Builder
+	word := []rune{}
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		w := string(word)
+		highlighted := false
+		for _, term := range terms {
+			if memoryTermScore(term, w) > 0 {
+				highlighted = true
+				break
+			}
+		}
+		if highlighted {
+			b.WriteString(searchHighlightStart + w + searchHighlightStop)
+		} else {
+			b.WriteString(w)
+		}
+		word = word[:0]
+	}
+
+	for _, r := range text {
+		if string(r) == searchHighlightStart || string(r) == searchHighlightStop {
+			continue
+		}
+		if isSearchSeparator(r) {
+			flush()
+			b.WriteRune(r)
+			continue
+		}
+		word = append(word, r)
+	}
+	flush()
+	return searchHighlightHTML(b.String())
+}
+
+// editDistanceAtMostOne reports whether a and b differ by at most one
+// inserted, deleted or substituted character.
+func editDistanceAtMostOne(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) > len(rb) {
+		ra, rb = rb, ra
+	}
+	if len(rb)-len(ra) > 1 {
+		return false
+	}
+
+	i, j, edits := 0, 0, 0
+	for i < len(ra) && j < len(rb) {
+		if ra[i] == rb[j] {
+			i++
+			j++
+			continue
+		}
+		edits++
+		if edits > 1 {
+			return false
+		}
+		if len(ra) == len(rb) {
+			i++
+		}
+		j++
+	}
+	return edits+max(len(ra)-i, len(rb)-j) <= 1
+}
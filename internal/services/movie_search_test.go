@@ -0,0 +1,139 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+func testSearcher() *MemoryMovieSearcher {
+	return NewMemoryMovieSearcher([]models.Movie{
+		{ID: 1, Title: "Heat", Director: "Michael Mann", Plot: "A thief plans one last heist."},
+		{ID: 2, Title: "Thief", Director: "Michael Mann", Plot: "A safecracker wants out."},
+		{ID: 3, Title: "Alien", Director: "Ridley Scott", Plot: "A crew meets a creature."},
+		{ID: 4, Title: "Aliens", Director: "James Cameron", Plot: "The creature is back, with friends."},
+		{ID: 5, Title: "Tom & Jerry <script>", Director: "Tim Story", Plot: "Cat <b>and</b> mouse."},
+	})
+}
+
+func resultIDs(results []models.MovieSearchResult) []uint {
+	ids := make([]uint, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+	return ids
+}
+
+func equalIDs(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMemoryMovieSearcherRanking(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []uint
+	}{
+		// A title match outranks a plot match.
+		{"thief", []uint{2, 1}},
+		// Every term has to match.
+		{"michael heist", []uint{1}},
+		// Words match by prefix, and ties are broken by ID.
+		{"alie", []uint{3, 4}},
+		{"creature", []uint{3, 4}},
+		{"", []uint{}},
+		{"nothing", []uint{}},
+	}
+	for _, test := range tests {
+		results, err := testSearcher().Search(test.query, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resultIDs(results); !equalIDs(got, test.want) {
+			t.Errorf("Search(%q) = %v, want %v", test.query, got, test.want)
+		}
+	}
+}
+
+func TestMemoryMovieSearcherFuzzy(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []uint
+	}{
+		// One substituted, missing or extra letter.
+		{"michal", []uint{1, 2}},
+		{"rdley", []uint{3}},
+		{"camerron", []uint{4}},
+		// Short words have to match exactly.
+		{"hea", []uint{1}},
+		{"hex", []uint{}},
+		// Two typos are too many.
+		{"mickal", []uint{}},
+	}
+	for _, test := range tests {
+		results, err := testSearcher().Search(test.query, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resultIDs(results); !equalIDs(got, test.want) {
+			t.Errorf("Search(%q) = %v, want %v", test.query, got, test.want)
+		}
+	}
+
+	exact, _ := testSearcher().Search("ridley", 1, 10)
+	fuzzy, _ := testSearcher().Search("rodley", 1, 10)
+	if len(exact) != 1 || len(fuzzy) != 1 || fuzzy[0].Rank >= exact[0].Rank {
+		t.Errorf("a typo should rank below an exact match: %v, %v", exact, fuzzy)
+	}
+}
+
+func TestMemoryMovieSearcherHighlight(t *testing.T) {
+	results, err := testSearcher().Search("mann thief", 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	want := models.MovieSearchHighlight{
+		Title:    "<mark>Thief</mark>",
+		Director: "Michael <mark>Mann</mark>",
+		Plot:     "A safecracker wants out.",
+	}
+	if got := results[0].Highlight; got != want {
+		t.Errorf("highlight = %+v, want %+v", got, want)
+	}
+}
+
+func TestMemoryMovieSearcherHighlightEscapesHTML(t *testing.T) {
+	results, err := testSearcher().Search("tom mouse", 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	want := models.MovieSearchHighlight{
+		Title:    "<mark>Tom</mark> &amp; Jerry &lt;script&gt;",
+		Director: "Tim Story",
+		Plot:     "Cat &lt;b&gt;and&lt;/b&gt; <mark>mouse</mark>.",
+	}
+	if got := results[0].Highlight; got != want {
+		t.Errorf("highlight = %+v, want %+v", got, want)
+	}
+}
+
+func TestSearchHighlightHTML(t *testing.T) {
+	snippet := "<img src=x onerror=alert(1)> " + searchHighlightStart + "Heat" + searchHighlightStop
+	want := "&lt;img src=x onerror=alert(1)&gt; <mark>Heat</mark>"
+	if got := searchHighlightHTML(snippet); got != want {
+		t.Errorf("searchHighlightHTML = %q, want %q", got, want)
+	}
+}
@@ -1,40 +1,83 @@
 package services
 
 import (
+    "context"
     "errors"
     
     "gorm.io/gorm"
     
     "github.com/mehmonov/movies-crud/internal/models"
+    "github.com/mehmonov/movies-crud/internal/repository"
 )
 
+const (
+    DefaultMoviePageSize = 20
+    MaxMoviePageSize     = 100
+)
+
+var (
+    ErrInvalidSort = repository.ErrInvalidSort
+    // ErrMovieVersionMismatch is returned by writes conditional on a movie
+    // version when the movie has been changed since.
+    ErrMovieVersionMismatch = repository.ErrVersionMismatch
+)
+
+// MovieService manages the catalog. Movies are stored in movies; the
+// credits, genres and revisions that change with them are written through
+// db, in the same transaction.
 type MovieService struct {
-    db *gorm.DB
+    db     *gorm.DB
+    movies repository.MovieRepository
 }
 
-func NewMovieService(db *gorm.DB) *MovieService {
-    return &MovieService{db: db}
+func NewMovieService(db *gorm.DB, movies repository.MovieRepository) *MovieService {
+    return &MovieService{db: db, movies: movies}
+}
+
+// transaction runs fn in a transaction, with a context that makes the
+// repositories take part in it.
+func (s *MovieService) transaction(fn func(ctx context.Context, tx *gorm.DB) error) error {
+    return s.db.Transaction(func(tx *gorm.DB) error {
+        return fn(repository.WithTx(context.Background(), tx), tx)
+    })
 }
 
-func (s *MovieService) GetAllMovies() ([]models.Movie, error) {
-    var movies []models.Movie
-    result := s.db.Find(&movies)
-    return movies, result.Error
+// movieNotFound turns repository.ErrNotFound into ErrMovieNotFound.
+func movieNotFound(err error) error {
+    if errors.Is(err, repository.ErrNotFound) {
+        return ErrMovieNotFound
+    }
+    return err
+}
+
+// GetAllMovies lists movies from a read replica, if there is any.
+func (s *MovieService) GetAllMovies(query *models.MovieListQuery) ([]models.Movie, int64, error) {
+    if query.Page < 1 {
+        query.Page = 1
+    }
+    if query.Limit < 1 {
+        query.Limit = DefaultMoviePageSize
+    }
+    if query.Limit > MaxMoviePageSize {
+        query.Limit = MaxMoviePageSize
+    }
+
+    return s.movies.List(repository.WithReplica(context.Background()), query)
 }
 
+// GetMovieByID reads a movie from the primary database, never from a
+// replica: its ETag is checked against the primary on every conditional
+// write, and a lagging replica would make it look stale.
 func (s *MovieService) GetMovieByID(id uint) (*models.Movie, error) {
-    var movie models.Movie
-    result := s.db.First(&movie, id)
-    if result.Error != nil {
-        if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-            return nil, nil 
-        }
-        return nil, result.Error
+    movie, err := s.movies.Get(context.Background(), id)
+    if errors.Is(err, repository.ErrNotFound) {
+        return nil, nil 
     }
-    return &movie, nil
+    return movie, err
 }
 
-func (s *MovieService) CreateMovie(req *models.CreateMovieRequest) (*models.Movie, error) {
+// CreateMovie adds a movie and records its creation by userID.
+func (s *MovieService) CreateMovie(req *models.CreateMovieRequest, userID uint) (*models.Movie, error) {
     movie := models.Movie{
         Title:    req.Title,
         Director: req.Director,
@@ -42,42 +85,118 @@ func (s *MovieService) CreateMovie(req *models.CreateMovieRequest) (*models.Movi
         Plot:     req.Plot,
     }
     
-    err := s.db.Transaction(func(tx *gorm.DB) error {
-        if err := tx.Create(&movie).Error; err != nil {
+    var created *models.Movie
+    err := s.transaction(func(ctx context.Context, tx *gorm.DB) error {
+        genres, err := findGenres(tx, req.GenreIDs)
+        if err != nil {
+            return err
+        }
+        movie.Genres = genres
+        
+        if err := s.movies.Create(ctx, &movie); err != nil {
             return err
         }
-        return nil
+        if err := setMovieDirectors(tx, movie.ID, req.Director); err != nil {
+            return err
+        }
+        
+        after, err := snapshotMovies(tx, []uint{movie.ID})
+        if err != nil {
+            return err
+        }
+        revision := models.MovieRevision{MovieID: movie.ID, Action: models.RevisionActionCreate}
+        if err := recordRevision(tx, &revision, userID, nil, after[movie.ID]); err != nil {
+            return err
+        }
+        
+        created, err = s.movies.Get(ctx, movie.ID)
+        return err
     })
     
     if err != nil {
         return nil, err
     }
     
-    return &movie, nil
+    return created, nil
 }
 
-func (s *MovieService) UpdateMovie(id uint, req *models.UpdateMovieRequest) error {
-    var movie models.Movie
-    if err := s.db.First(&movie, id).Error; err != nil {
-        return err
-    }
-    
-    if req.Title != "" {
-        movie.Title = req.Title
-    }
-    if req.Director != "" {
-        movie.Director = req.Director
-    }
-    if req.Year != 0 {
-        movie.Year = req.Year
-    }
-    if req.Plot != "" {
-        movie.Plot = req.Plot
-    }
-    
-    return s.db.Save(&movie).Error
+// UpdateMovie changes the fields set in req and records the change by
+// userID. Unless version is 0, the update only applies to that version of
+// the movie and fails with ErrMovieVersionMismatch otherwise.
+func (s *MovieService) UpdateMovie(id uint, version int, req *models.UpdateMovieRequest, userID uint) error {
+    return s.transaction(func(ctx context.Context, tx *gorm.DB) error {
+        movie, err := s.movies.GetForUpdate(ctx, id, version)
+        if err != nil {
+            return movieNotFound(err)
+        }
+        before, err := snapshotMovies(tx, []uint{movie.ID})
+        if err != nil {
+            return err
+        }
+        
+        if req.Title != "" {
+            movie.Title = req.Title
+        }
+        if req.Year != 0 {
+            movie.Year = req.Year
+        }
+        if req.Plot != "" {
+            movie.Plot = req.Plot
+        }
+        
+        if err := s.movies.Update(ctx, movie); err != nil {
+            return err
+        }
+        
+        if req.Director != "" {
+            if err := setMovieDirectors(tx, movie.ID, req.Director); err != nil {
+                return err
+            }
+        }
+        
+        if req.GenreIDs != nil {
+            genres, err := findGenres(tx, req.GenreIDs)
+            if err != nil {
+                return err
+            }
+            if err := s.movies.SetGenres(ctx, movie.ID, genres); err != nil {
+                return err
+            }
+        }
+        
+        after, err := snapshotMovies(tx, []uint{movie.ID})
+        if err != nil {
+            return err
+        }
+        revision := models.MovieRevision{MovieID: movie.ID, Action: models.RevisionActionUpdate}
+        return recordRevision(tx, &revision, userID, before[movie.ID], after[movie.ID])
+    })
 }
 
-func (s *MovieService) DeleteMovie(id uint) error {
-    return s.db.Delete(&models.Movie{}, id).Error
+// DeleteMovie soft-deletes the movie and takes it off every watchlist.
+// Watched history is kept but hidden while the movie is deleted; rows that
+// reference the movie are only removed when it is purged from the trash, see
+// TrashService. The deletion is recorded as a revision by userID. Unknown
+// and already deleted movies give ErrMovieNotFound. Unless version is 0,
+// only that version of the movie is deleted, as in UpdateMovie.
+func (s *MovieService) DeleteMovie(id uint, version int, userID uint) error {
+    return s.transaction(func(ctx context.Context, tx *gorm.DB) error {
+        if _, err := s.movies.GetForUpdate(ctx, id, version); err != nil {
+            return movieNotFound(err)
+        }
+        before, err := snapshotMovies(tx, []uint{id})
+        if err != nil {
+            return err
+        }
+        
+        if err := s.movies.Delete(ctx, id, version); err != nil {
+            return movieNotFound(err)
+        }
+        if err := tx.Where("movie_id = ?", id).Delete(&models.WatchlistItem{}).Error; err != nil {
+            return err
+        }
+        
+        revision := models.MovieRevision{MovieID: id, Action: models.RevisionActionDelete}
+        return recordRevision(tx, &revision, userID, before[id], nil)
+    })
 }
\ No newline at end of file
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+
+	"github.com/mehmonov/movies-crud/config"
+	"github.com/mehmonov/movies-crud/internal/db"
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/repository"
+)
+
+// testDatabase returns a new in-memory SQLite database with the schema of
+// the migrations.
+func testDatabase(t *testing.T, cfg *config.Config) *gorm.DB {
+	t.Helper()
+	cfg.DB.Driver = config.DBDriverSQLite
+	cfg.DB.Path = ":memory:"
+	database, err := db.NewDatabase(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close(database) })
+	return database
+}
+
+func testMovieService(t *testing.T) (*MovieService, *models.User) {
+	t.Helper()
+	database := testDatabase(t, config.Default())
+	user := &models.User{Username: "editor", Password: "x", Role: models.RoleEditor}
+	if err := database.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return NewMovieService(database, repository.NewGormMovieRepository(database)), user
+}
+
+func TestMovieVersionBumpsOncePerChange(t *testing.T) {
+	service, user := testMovieService(t)
+	movie, err := service.CreateMovie(&models.CreateMovieRequest{Title: "Heat", Director: "Michael Mann", Year: 1995}, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if movie.Version != 1 {
+		t.Fatalf("created at version %d, want 1", movie.Version)
+	}
+
+	steps := []struct {
+		name   string
+		change func(version int) error
+	}{
+		{"update", func(version int) error {
+			return service.UpdateMovie(movie.ID, version, &models.UpdateMovieRequest{Director: "Joel Coen & Ethan Coen"}, user.ID)
+		}},
+		{"merge patch", func(version int) error {
+			return service.PatchMovie(movie.ID, version, models.PatchTypeMerge, []byte(`{"director":"Ridley Scott"}`), user.ID)
+		}},
+	}
+	for i, step := range steps {
+		if err := step.change(i + 1); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		movie, err = service.GetMovieByID(movie.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if movie.Version != i+2 {
+			t.Errorf("%s moved the movie to version %d, want %d", step.name, movie.Version, i+2)
+		}
+	}
+	if movie.Director != "Ridley Scott" {
+		t.Errorf("director = %q, want Ridley Scott", movie.Director)
+	}
+}
+
+func TestRevertMovie(t *testing.T) {
+	service, user := testMovieService(t)
+	crime := models.Genre{Name: "Crime"}
+	drama := models.Genre{Name: "Drama"}
+	if err := service.db.Create([]*models.Genre{&crime, &drama}).Error; err != nil {
+		t.Fatal(err)
+	}
+	movie, err := service.CreateMovie(&models.CreateMovieRequest{Title: "Heat", Director: "Michael Mann", Year: 1995, GenreIDs: []uint{crime.ID, drama.ID}}, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.UpdateMovie(movie.ID, 0, &models.UpdateMovieRequest{Title: "Heat 2", GenreIDs: []uint{}}, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	revisions, err := service.GetMovieRevisions(movie.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := revisions[len(revisions)-1].ID
+	if err := service.db.Delete(&drama).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = service.RevertMovie(movie.ID, created, 1, true, user.ID)
+	if !errors.Is(err, ErrMovieVersionMismatch) {
+		t.Errorf("revert of a stale version: got %v, want ErrMovieVersionMismatch", err)
+	}
+
+	_, err = service.RevertMovie(movie.ID, created, 0, false, user.ID)
+	var missing *MissingGenresError
+	if !errors.As(err, &missing) || !equalIDs(missing.IDs, []uint{drama.ID}) || !errors.Is(err, ErrGenreNotFound) {
+		t.Fatalf("revert with a deleted genre: got %v, want the genre reported missing", err)
+	}
+	if movie, err = service.GetMovieByID(movie.ID); err != nil || movie.Title != "Heat 2" || movie.Version != 2 {
+		t.Fatalf("a failed revert changed the movie: %+v, %v", movie, err)
+	}
+
+	movie, err = service.RevertMovie(movie.ID, created, 2, true, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if movie.Title != "Heat" || movie.Version != 3 || len(movie.Genres) != 1 || movie.Genres[0].ID != crime.ID {
+		t.Errorf("reverted to %q at version %d with genres %v; want Heat at 3 with only %d", movie.Title, movie.Version, movie.Genres, crime.ID)
+	}
+}
+
+func TestGetMovieByIDReadsThePrimary(t *testing.T) {
+	service, user := testMovieService(t)
+	movie, err := service.CreateMovie(&models.CreateMovieRequest{Title: "Heat", Director: "Michael Mann", Year: 1995}, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An empty replica stands in for one that has not caught up yet.
+	replica := dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{sqlite.Open(":memory:")},
+	}, db.ReplicaResolver)
+	if err := service.db.Use(replica); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := service.GetMovieByID(movie.ID)
+	if err != nil || got == nil || got.Version != movie.Version {
+		t.Errorf("GetMovieByID = %+v, %v; want the movie from the primary", got, err)
+	}
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/repository"
+)
+
+var ErrPersonNotFound = errors.New("person not found")
+
+type PersonService struct {
+	db *gorm.DB
+}
+
+func NewPersonService(db *gorm.DB) *PersonService {
+	return &PersonService{db: db}
+}
+
+func (s *PersonService) GetAllPeople(query *models.PersonListQuery) ([]models.Person, int64, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 {
+		query.Limit = DefaultMoviePageSize
+	}
+
+	filtered := s.db.Model(&models.Person{})
+	if query.Name != "" {
+		filtered = filtered.Where(`LOWER(name) LIKE ? ESCAPE '\'`, repository.ContainsPattern(query.Name))
+	}
+	filtered = filtered.Session(&gorm.Session{})
+
+	var total int64
+	if err := filtered.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	people := []models.Person{}
+	result := filtered.
+		Order("name ASC, id ASC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&people)
+	return people, total, result.Error
+}
+
+func (s *PersonService) GetPersonByID(id uint) (*models.Person, error) {
+	var person models.Person
+	result := s.db.First(&person, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &person, nil
+}
+
+func (s *PersonService) CreatePerson(req *models.PersonRequest) (*models.Person, error) {
+	person := models.Person{Name: strings.TrimSpace(req.Name)}
+	if err := s.db.Create(&person).Error; err != nil {
+		return nil, err
+	}
+	return &person, nil
+}
+
+// UpdatePerson renames a person and refreshes the director names of every
+// movie they directed.
+func (s *PersonService) UpdatePerson(id uint, req *models.PersonRequest) (*models.Person, error) {
+	var person models.Person
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&person, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPersonNotFound
+			}
+			return err
+		}
+
+		person.Name = strings.TrimSpace(req.Name)
+		if err := tx.Save(&person).Error; err != nil {
+			return err
+		}
+
+		var movieIDs []uint
+		err := tx.Model(&models.Credit{}).
+			Where("person_id = ? AND role = ?", id, models.CreditRoleDirector).
+			Distinct().
+			Pluck("movie_id", &movieIDs).Error
+		if err != nil {
+			return err
+		}
+		for _, movieID := range movieIDs {
+			if err := syncMovieDirector(tx, movieID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &person, nil
+}
+
+// GetFilmography returns the person's credits on movies that have not been
+// deleted, newest movie first. It returns ErrPersonNotFound for unknown IDs.
+func (s *PersonService) GetFilmography(id uint) ([]models.Credit, error) {
+	var count int64
+	if err := s.db.Model(&models.Person{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrPersonNotFound
+	}
+
+	credits := []models.Credit{}
+	result := s.db.
+		InnerJoins("Movie").
+		Where("credits.person_id = ?", id).
+		Order(`"Movie".year DESC, credits.role ASC, credits.billing_order ASC`).
+		Find(&credits)
+	return credits, result.Error
+}
+
+// findOrCreatePerson returns the person whose name matches name ignoring case
+// and surrounding spaces, creating them if there is none.
+func findOrCreatePerson(tx *gorm.DB, name string) (*models.Person, error) {
+	name = strings.TrimSpace(name)
+
+	var person models.Person
+	err := tx.Where("LOWER(name) = LOWER(?)", name).Order("id").First(&person).Error
+	if err == nil {
+		return &person, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	person = models.Person{Name: name}
+	if err := tx.Create(&person).Error; err != nil {
+		return nil, err
+	}
+	return &person, nil
+}
+
+// splitPersonNames splits a free-text credit such as "Joel Coen & Ethan Coen"
+// into the individual names.
+func splitPersonNames(names string) []string {
+	var result []string
+	for _, name := range strings.FieldsFunc(names, func(r rune) bool { return r == ',' || r == '&' }) {
+		if name = strings.TrimSpace(name); name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// setMovieDirectors replaces the director credits of the movie with the
+// people named in directors and refreshes its Director column. It is part of
+// a change that already moves the movie to its next version, so it does not
+// bump the version itself.
+func setMovieDirectors(tx *gorm.DB, movieID uint, directors string) error {
+	err := tx.Where("movie_id = ? AND role = ?", movieID, models.CreditRoleDirector).
+		Delete(&models.Credit{}).Error
+	if err != nil {
+		return err
+	}
+
+	for i, name := range splitPersonNames(directors) {
+		person, err := findOrCreatePerson(tx, name)
+		if err != nil {
+			return err
+		}
+
+		credit := models.Credit{
+			MovieID:      movieID,
+			PersonID:     person.ID,
+			Role:         models.CreditRoleDirector,
+			BillingOrder: i,
+		}
+		if err := tx.Create(&credit).Error; err != nil {
+			return err
+		}
+	}
+
+	return writeMovieDirector(tx, movieID, false)
+}
+
+// syncMovieDirector rewrites the movie's Director column from its director
+// credits, moving the movie to a new version when it changes.
+func syncMovieDirector(tx *gorm.DB, movieID uint) error {
+	return writeMovieDirector(tx, movieID, true)
+}
+
+// writeMovieDirector rewrites the movie's Director column from its director
+// credits and, if bumpVersion is set, moves the movie to a new version when
+// the column changes.
+func writeMovieDirector(tx *gorm.DB, movieID uint, bumpVersion bool) error {
+	var names []string
+	err := tx.Model(&models.Credit{}).
+		Joins("JOIN people ON people.id = credits.person_id").
+		Where("credits.movie_id = ? AND credits.role = ?", movieID, models.CreditRoleDirector).
+		Order("credits.billing_order ASC, credits.id ASC").
+		Pluck("people.name", &names).Error
+	if err != nil {
+		return err
+	}
+
+	director := []rune(strings.Join(names, ", "))
+	if len(director) > 100 {
+		director = director[:100]
+	}
+
+	columns := map[string]interface{}{"director": string(director)}
+	if bumpVersion {
+		columns["version"] = gorm.Expr("version + 1")
+	}
+	return tx.Model(&models.Movie{}).
+		Where("id = ? AND director <> ?", movieID, string(director)).
+		UpdateColumns(columns).Error
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+var (
+	ErrReviewExists   = errors.New("you have already reviewed this movie")
+	ErrReviewNotFound = errors.New("review not found")
+)
+
+type ReviewService struct {
+	db *gorm.DB
+}
+
+func NewReviewService(db *gorm.DB) *ReviewService {
+	return &ReviewService{db: db}
+}
+
+func (s *ReviewService) GetMovieReviews(movieID uint, query *models.ReviewListQuery) ([]models.Review, int64, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 {
+		query.Limit = DefaultMoviePageSize
+	}
+
+	if err := ensureMovieExists(s.db, movieID); err != nil {
+		return nil, 0, err
+	}
+
+	filtered := s.db.Model(&models.Review{}).Where("movie_id = ?", movieID).Session(&gorm.Session{})
+
+	var total int64
+	if err := filtered.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	reviews := []models.Review{}
+	result := filtered.
+		Preload("User").
+		Order("created_at DESC, id DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&reviews)
+	return reviews, total, result.Error
+}
+
+func (s *ReviewService) CreateReview(movieID, userID uint, req *models.ReviewRequest) (*models.Review, error) {
+	review := models.Review{
+		MovieID: movieID,
+		UserID:  userID,
+		Score:   req.Score,
+		Text:    req.Text,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockMovie(tx, movieID); err != nil {
+			return err
+		}
+
+		var count int64
+		err := tx.Model(&models.Review{}).
+			Where("movie_id = ? AND user_id = ?", movieID, userID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrReviewExists
+		}
+
+		if err := tx.Create(&review).Error; err != nil {
+			return err
+		}
+		return recomputeMovieRating(tx, movieID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+func (s *ReviewService) UpdateReview(movieID, userID uint, req *models.ReviewRequest) (*models.Review, error) {
+	var review models.Review
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockMovie(tx, movieID); err != nil {
+			return err
+		}
+
+		err := tx.Where("movie_id = ? AND user_id = ?", movieID, userID).First(&review).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrReviewNotFound
+			}
+			return err
+		}
+
+		review.Score = req.Score
+		review.Text = req.Text
+		if err := tx.Save(&review).Error; err != nil {
+			return err
+		}
+		return recomputeMovieRating(tx, movieID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+func (s *ReviewService) DeleteReview(movieID, userID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockMovie(tx, movieID); err != nil {
+			return err
+		}
+
+		result := tx.Where("movie_id = ? AND user_id = ?", movieID, userID).Delete(&models.Review{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrReviewNotFound
+		}
+		return recomputeMovieRating(tx, movieID)
+	})
+}
+
+// lockMovie takes a row lock on the movie for the rest of the transaction so
+// that concurrent changes to its reviews are applied one after another. It
+// returns ErrMovieNotFound for unknown or deleted movies.
+func lockMovie(tx *gorm.DB, movieID uint) error {
+	var movie models.Movie
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&movie, movieID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrMovieNotFound
+	}
+	return err
+}
+
+// recomputeMovieRating recalculates the movie's Rating and Votes from its
+// reviews. Callers must hold the lock from lockMovie.
+func recomputeMovieRating(tx *gorm.DB, movieID uint) error {
+	var stats struct {
+		Rating float64
+		Votes  int
+	}
+	err := tx.Model(&models.Review{}).
+		Select("COALESCE(AVG(score), 0) AS rating, COUNT(*) AS votes").
+		Where("movie_id = ?", movieID).
+		Scan(&stats).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Movie{}).
+		Where("id = ?", movieID).
+		UpdateColumns(map[string]interface{}{
+			"rating": stats.Rating,
+			"votes":  stats.Votes,
+		}).Error
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/mehmonov/movies-crud/config"
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/storage"
+	"github.com/mehmonov/movies-crud/pkg/subtitle"
+)
+
+var (
+	ErrSubtitleNotFound    = errors.New("subtitle track not found")
+	ErrInvalidLanguage     = errors.New("language must be a language tag such as en or pt-BR")
+	ErrSubtitleTooLarge    = errors.New("subtitle file exceeds the upload size limit")
+	ErrSubtitleOffsetRange = errors.New("offset_ms must be within one hour")
+)
+
+// languageTag matches BCP 47 tags made of a language and optional subtags,
+// such as "en", "pt-BR" or "zh-Hant".
+var languageTag = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// maxSubtitleOffset bounds the timing offset of a track.
+const maxSubtitleOffset = time.Hour
+
+// SubtitleService stores one subtitle track per movie and language. Files
+// are kept as uploaded in the same BlobStore as media, under
+// movie_<id>/subtitles/, and converted to WebVTT when served.
+type SubtitleService struct {
+	db      *gorm.DB
+	store   storage.BlobStore
+	maxSize int64
+}
+
+func NewSubtitleService(cfg *config.Config, db *gorm.DB, store storage.BlobStore) *SubtitleService {
+	return &SubtitleService{
+		db:      db,
+		store:   store,
+		maxSize: cfg.Storage.MaxSubtitleSize,
+	}
+}
+
+// MaxUploadSize is the largest subtitle file, in bytes, that can be uploaded.
+func (s *SubtitleService) MaxUploadSize() int64 {
+	return s.maxSize
+}
+
+func (s *SubtitleService) GetMovieSubtitles(movieID uint) ([]models.SubtitleTrack, error) {
+	if err := ensureMovieExists(s.db, movieID); err != nil {
+		return nil, err
+	}
+
+	var tracks []models.SubtitleTrack
+	err := s.db.Where("movie_id = ?", movieID).Order("language ASC").Find(&tracks).Error
+	return tracks, err
+}
+
+// SetSubtitle validates the SRT or WebVTT file read from r and stores it as
+// the movie's track for language, replacing the previous one. Malformed
+// files are rejected with a subtitle.ErrorList.
+func (s *SubtitleService) SetSubtitle(ctx context.Context, movieID uint, language string, req *models.SubtitleUploadRequest, r io.Reader) (*models.SubtitleTrack, error) {
+	if !languageTag.MatchString(language) {
+		return nil, ErrInvalidLanguage
+	}
+	if err := checkSubtitleOffset(req.OffsetMs); err != nil {
+		return nil, err
+	}
+	if err := ensureMovieExists(s.db, movieID); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, ErrSubtitleTooLarge
+	}
+
+	format, cues, err := subtitle.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	track := models.SubtitleTrack{
+		MovieID:    movieID,
+		Language:   language,
+		Label:      req.Label,
+		Format:     format,
+		StorageKey: fmt.Sprintf("movie_%d/subtitles/%s_%s.%s", movieID, language, hex.EncodeToString(sum[:]), format),
+		CueCount:   len(cues),
+		OffsetMs:   req.OffsetMs,
+	}
+	if _, err := s.store.Put(ctx, track.StorageKey, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	var previous models.SubtitleTrack
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockMovie(tx, movieID); err != nil {
+			return err
+		}
+		err := tx.Where("movie_id = ? AND language = ?", movieID, language).First(&previous).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(&track).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		track.ID = previous.ID
+		track.CreatedAt = previous.CreatedAt
+		return tx.Save(&track).Error
+	})
+	if err != nil {
+		if previous.StorageKey != track.StorageKey {
+			s.deleteFile(ctx, track.StorageKey)
+		}
+		return nil, err
+	}
+
+	// Uploading the same file again reuses it.
+	if previous.StorageKey != "" && previous.StorageKey != track.StorageKey {
+		s.deleteFile(ctx, previous.StorageKey)
+	}
+	return &track, nil
+}
+
+// GetVTT returns the movie's track for language as WebVTT, shifted by the
+// track's offset plus extra.
+func (s *SubtitleService) GetVTT(ctx context.Context, movieID uint, language string, extra time.Duration) (*models.SubtitleTrack, []byte, error) {
+	track, err := s.getTrack(movieID, language)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blob, err := s.store.Open(ctx, track.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrSubtitleNotFound
+		}
+		return nil, nil, err
+	}
+	defer blob.Close()
+
+	data, err := io.ReadAll(blob)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, cues, err := subtitle.Parse(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	offset := time.Duration(track.OffsetMs)*time.Millisecond + extra
+	return track, subtitle.WriteVTT(subtitle.Shift(cues, offset)), nil
+}
+
+// UpdateSubtitle changes the label or timing offset of a track without
+// uploading the file again.
+func (s *SubtitleService) UpdateSubtitle(movieID uint, language string, req *models.UpdateSubtitleRequest) (*models.SubtitleTrack, error) {
+	track, err := s.getTrack(movieID, language)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if req.Label != nil {
+		updates["label"] = *req.Label
+	}
+	if req.OffsetMs != nil {
+		if err := checkSubtitleOffset(*req.OffsetMs); err != nil {
+			return nil, err
+		}
+		updates["offset_ms"] = *req.OffsetMs
+	}
+	if len(updates) > 0 {
+		if err := s.db.Model(track).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+	return track, nil
+}
+
+func (s *SubtitleService) DeleteSubtitle(ctx context.Context, movieID uint, language string) error {
+	track, err := s.getTrack(movieID, language)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(track).Error; err != nil {
+		return err
+	}
+
+	s.deleteFile(ctx, track.StorageKey)
+	return nil
+}
+
+func (s *SubtitleService) getTrack(movieID uint, language string) (*models.SubtitleTrack, error) {
+	var track models.SubtitleTrack
+	err := s.db.Where("movie_id = ? AND language = ?", movieID, language).First(&track).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSubtitleNotFound
+		}
+		return nil, err
+	}
+	return &track, nil
+}
+
+// deleteFile removes a stored subtitle file. Failures are only logged, as
+// the record is already gone.
+func (s *SubtitleService) deleteFile(ctx context.Context, key string) {
+	if err := s.store.Delete(ctx, key); err != nil {
+		log.Printf("Deleting subtitle file %s: %v", key, err)
+	}
+}
+
+func checkSubtitleOffset(ms int64) error {
+	if ms > maxSubtitleOffset.Milliseconds() || ms < -maxSubtitleOffset.Milliseconds() {
+		return ErrSubtitleOffsetRange
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/mehmonov/movies-crud/config"
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/pkg/auth"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when a refresh token that was
+	// already rotated is presented again. The whole token family is revoked
+	// because the token has most likely been stolen.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected, please log in again")
+)
+
+// TokenService issues access tokens together with rotating refresh tokens.
+type TokenService struct {
+	db         *gorm.DB
+	jwtService *auth.JWTService
+	refreshTTL time.Duration
+}
+
+func NewTokenService(cfg *config.Config, db *gorm.DB, jwtService *auth.JWTService) *TokenService {
+	return &TokenService{
+		db:         db,
+		jwtService: jwtService,
+		refreshTTL: cfg.Auth.RefreshTokenTTL,
+	}
+}
+
+// IssueTokens starts a new session for user and returns its first access
+// and refresh tokens.
+func (s *TokenService) IssueTokens(user *models.User) (*models.AuthResponse, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	var response *models.AuthResponse
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Drop this user's expired tokens so the table does not grow forever.
+		err := tx.Where("user_id = ? AND expires_at < ?", user.ID, time.Now()).
+			Delete(&models.RefreshToken{}).Error
+		if err != nil {
+			return err
+		}
+
+		response, err = s.issue(tx, user, familyID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token.
+// The presented token can not be used again.
+func (s *TokenService) Refresh(refreshToken string) (*models.AuthResponse, error) {
+	var response *models.AuthResponse
+	var reused bool
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var stored models.RefreshToken
+		err := tx.Where("token_hash = ?", hashToken(refreshToken)).First(&stored).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		now := time.Now()
+		if stored.RevokedAt != nil {
+			reused = true
+			return revokeFamily(tx, stored.FamilyID, now)
+		}
+		if now.After(stored.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		// Only one concurrent request can rotate the token; any other one
+		// sees it as reused.
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", stored.ID).
+			Update("revoked_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			reused = true
+			return revokeFamily(tx, stored.FamilyID, now)
+		}
+
+		var user models.User
+		if err := tx.First(&user, stored.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		response, err = s.issue(tx, &user, stored.FamilyID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, ErrRefreshTokenReused
+	}
+
+	return response, nil
+}
+
+// Revoke ends the session the refresh token belongs to. Unknown tokens are
+// ignored so that logging out is idempotent.
+func (s *TokenService) Revoke(refreshToken string) error {
+	var stored models.RefreshToken
+	err := s.db.Where("token_hash = ?", hashToken(refreshToken)).First(&stored).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return revokeFamily(s.db, stored.FamilyID, time.Now())
+}
+
+func (s *TokenService) issue(tx *gorm.DB, user *models.User, familyID string) (*models.AuthResponse, error) {
+	accessToken, err := s.jwtService.GenerateToken(user.ID, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}
+	if err := tx.Omit("User").Create(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	// Create a safe user response without password
+	safeUser := &models.User{
+		ID:        user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+
+	return &models.AuthResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.jwtService.TTL().Seconds()),
+		User:         safeUser,
+	}, nil
+}
+
+func revokeFamily(tx *gorm.DB, familyID string, at time.Time) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
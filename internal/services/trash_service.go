@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mehmonov/movies-crud/config"
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/storage"
+)
+
+const (
+	// trashPurgeInterval is how often the retention job looks for movies
+	// that have been in the trash for too long.
+	trashPurgeInterval = time.Hour
+	// trashPurgeBatchSize is the number of movies purged per transaction by
+	// the retention job.
+	trashPurgeBatchSize = 100
+)
+
+// movieDependents are the rows removed with a movie when it is purged.
+var movieDependents = []interface{}{
+	&models.Credit{},
+	&models.Review{},
+	&models.WatchlistItem{},
+	&models.WatchedEntry{},
+	&models.MediaFile{},
+	&models.UploadSession{},
+	&models.MovieImage{},
+	&models.SubtitleTrack{},
+	&models.MovieRevision{},
+}
+
+// TrashService manages soft-deleted movies: they can be listed, restored or
+// purged for good. When a retention is configured, a background job purges
+// the movies deleted longer ago than that.
+type TrashService struct {
+	db        *gorm.DB
+	store     storage.BlobStore
+	retention time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewTrashService(cfg *config.Config, db *gorm.DB, store storage.BlobStore) *TrashService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TrashService{
+		db:        db,
+		store:     store,
+		retention: cfg.Movies.TrashRetention,
+		ctx:       ctx,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+}
+
+// GetDeletedMovies lists the movies in the trash, most recently deleted
+// first.
+func (s *TrashService) GetDeletedMovies(query *models.DeletedMovieListQuery) ([]models.DeletedMovie, int64, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 {
+		query.Limit = DefaultMoviePageSize
+	}
+
+	deleted := s.db.Unscoped().Model(&models.Movie{}).Where("deleted_at IS NOT NULL").Session(&gorm.Session{})
+
+	var total int64
+	if err := deleted.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var movies []models.Movie
+	err := deleted.
+		Preload("Genres").
+		Preload("Images").
+		Order("deleted_at DESC, id DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&movies).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]models.DeletedMovie, len(movies))
+	for i, movie := range movies {
+		items[i] = models.DeletedMovie{Movie: movie, DeletedAt: movie.DeletedAt.Time}
+	}
+	return items, total, nil
+}
+
+// RestoreMovies takes the given movies out of the trash and records each
+// restore as a revision by userID. Watchlist entries removed by the delete
+// are not brought back.
+func (s *TrashService) RestoreMovies(ids []uint, userID uint) (*models.TrashResponse, error) {
+	var response *models.TrashResponse
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		found, err := lockDeletedMovies(tx, ids)
+		if err != nil {
+			return err
+		}
+		response = trashResponse(ids, found)
+		if len(found) == 0 {
+			return nil
+		}
+
+		err = tx.Unscoped().Model(&models.Movie{}).Where("id IN ?", found).Update("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+
+		after, err := snapshotMovies(tx, found)
+		if err != nil {
+			return err
+		}
+		for _, id := range found {
+			revision := models.MovieRevision{MovieID: id, Action: models.RevisionActionRestore}
+			if err := recordRevision(tx, &revision, userID, nil, after[id]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// PurgeMovies permanently deletes the given movies from the trash, with
+// everything that refers to them: credits, reviews, watched history, media,
+// images, subtitles and edit history, and their stored files.
+func (s *TrashService) PurgeMovies(ctx context.Context, ids []uint) (*models.TrashResponse, error) {
+	var response *models.TrashResponse
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		found, err := lockDeletedMovies(tx, ids)
+		if err != nil {
+			return err
+		}
+		response = trashResponse(ids, found)
+		if len(found) == 0 {
+			return nil
+		}
+
+		if err := tx.Exec("DELETE FROM movie_genres WHERE movie_id IN ?", found).Error; err != nil {
+			return err
+		}
+		for _, model := range movieDependents {
+			if err := tx.Unscoped().Where("movie_id IN ?", found).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(&models.Movie{}, found).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The rows are gone, so failing to remove a file only leaves it behind.
+	for _, id := range response.IDs {
+		if err := s.store.DeleteDir(ctx, fmt.Sprintf("movie_%d", id)); err != nil {
+			log.Printf("Deleting files of purged movie %d: %v", id, err)
+		}
+	}
+	return response, nil
+}
+
+// Start runs the retention job, unless the retention is zero.
+func (s *TrashService) Start() {
+	if s.retention <= 0 {
+		close(s.done)
+		return
+	}
+	go s.run()
+}
+
+// Stop stops the retention job.
+func (s *TrashService) Stop(ctx context.Context) error {
+	s.cancel()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *TrashService) run() {
+	defer close(s.done)
+
+	for {
+		if err := s.purgeExpired(); err != nil && s.ctx.Err() == nil {
+			log.Printf("Purging the trash: %v", err)
+		}
+
+		select {
+		case <-time.After(trashPurgeInterval):
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// purgeExpired purges the movies deleted longer ago than the retention.
+func (s *TrashService) purgeExpired() error {
+	for {
+		var ids []uint
+		err := s.db.WithContext(s.ctx).Unscoped().Model(&models.Movie{}).
+			Where("deleted_at < ?", time.Now().Add(-s.retention)).
+			Order("deleted_at").
+			Limit(trashPurgeBatchSize).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		response, err := s.PurgeMovies(s.ctx, ids)
+		if err != nil {
+			return err
+		}
+		log.Printf("Purged %d movies deleted more than %s ago", len(response.IDs), s.retention)
+		if len(response.IDs) == 0 {
+			// Restored in the meantime; pick the rest up next time.
+			return nil
+		}
+	}
+}
+
+// lockDeletedMovies locks the movies among ids that are in the trash and
+// returns their IDs.
+func lockDeletedMovies(tx *gorm.DB, ids []uint) ([]uint, error) {
+	found := []uint{}
+	err := tx.Unscoped().Model(&models.Movie{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ? AND deleted_at IS NOT NULL", ids).
+		Order("id").
+		Pluck("id", &found).Error
+	return found, err
+}
+
+func trashResponse(ids, found []uint) *models.TrashResponse {
+	response := &models.TrashResponse{IDs: found, NotFound: []uint{}}
+	ok := make(map[uint]bool, len(found))
+	for _, id := range found {
+		ok[id] = true
+	}
+	for _, id := range ids {
+		if !ok[id] {
+			response.NotFound = append(response.NotFound, id)
+			ok[id] = true
+		}
+	}
+	return response
+}
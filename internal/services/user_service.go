@@ -1,29 +1,32 @@
 package services
 
 import (
+    "context"
     "errors"
+    "fmt"
     "golang.org/x/crypto/bcrypt"
-    "gorm.io/gorm"
 
     "github.com/mehmonov/movies-crud/internal/models"
+    "github.com/mehmonov/movies-crud/internal/repository"
+)
+
+var (
+    ErrUserNotFound = errors.New("user not found")
+    // ErrAdminPasswordMismatch is returned by EnsureAdmin when the account
+    // to promote does not have the admin password, as anyone can register
+    // a username before the admin is bootstrapped.
+    ErrAdminPasswordMismatch = errors.New("admin password does not match the existing account")
 )
 
 type UserService struct {
-    db *gorm.DB
+    users repository.UserRepository
 }
 
-func NewUserService(db *gorm.DB) *UserService {
-    return &UserService{db: db}
+func NewUserService(users repository.UserRepository) *UserService {
+    return &UserService{users: users}
 }
 
 func (s *UserService) CreateUser(req *models.CreateUserRequest) (*models.User, error) {
-
-
-    var existingUser models.User
-    if err := s.db.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
-        return nil, errors.New("username already exists")
-    }
-
     hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
     if err != nil {
         return nil, err
@@ -32,9 +35,13 @@ func (s *UserService) CreateUser(req *models.CreateUserRequest) (*models.User, e
     user := models.User{
         Username: req.Username,
         Password: string(hashedPassword),
+        Role:     models.RoleViewer,
     }
 
-    if err := s.db.Create(&user).Error; err != nil {
+    if err := s.users.Create(context.Background(), &user); err != nil {
+        if errors.Is(err, repository.ErrDuplicate) {
+            return nil, errors.New("username already exists")
+        }
         return nil, err
     }
 
@@ -44,9 +51,64 @@ func (s *UserService) CreateUser(req *models.CreateUserRequest) (*models.User, e
 }
 
 func (s *UserService) GetUserByUsername(username string) (*models.User, error) {
-    var user models.User
-    if err := s.db.Where("username = ?", username).First(&user).Error; err != nil {
+    return s.users.GetByUsername(context.Background(), username)
+}
+
+func (s *UserService) UpdateUserRole(id uint, role string) (*models.User, error) {
+    ctx := context.Background()
+    if err := s.users.UpdateRole(ctx, id, role); err != nil {
+        if errors.Is(err, repository.ErrNotFound) {
+            return nil, ErrUserNotFound
+        }
         return nil, err
     }
-    return &user, nil
-} 
\ No newline at end of file
+
+    user, err := s.users.Get(ctx, id)
+    if err != nil {
+        return nil, err
+    }
+    user.Password = ""
+    return user, nil
+}
+
+// EnsureAdmin bootstraps the first administrator. If no admin exists yet, the
+// user called username is created with password, or promoted to admin if it
+// already exists and password is its password. Otherwise it fails with
+// ErrAdminPasswordMismatch. It does nothing once an admin exists.
+func (s *UserService) EnsureAdmin(username, password string) error {
+    return s.users.Transaction(context.Background(), func(ctx context.Context) error {
+        admins, err := s.users.CountByRole(ctx, models.RoleAdmin)
+        if err != nil {
+            return err
+        }
+        if admins > 0 {
+            return nil
+        }
+
+        if password == "" {
+            return errors.New("admin password is required to create or promote the admin user")
+        }
+
+        user, err := s.users.GetByUsername(ctx, username)
+        if err == nil {
+            if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+                return fmt.Errorf("cannot promote %q: %w", username, ErrAdminPasswordMismatch)
+            }
+            return s.users.UpdateRole(ctx, user.ID, models.RoleAdmin)
+        }
+        if !errors.Is(err, repository.ErrNotFound) {
+            return err
+        }
+
+        hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+        if err != nil {
+            return err
+        }
+
+        return s.users.Create(ctx, &models.User{
+            Username: username,
+            Password: string(hashedPassword),
+            Role:     models.RoleAdmin,
+        })
+    })
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mehmonov/movies-crud/config"
+	"github.com/mehmonov/movies-crud/internal/models"
+	"github.com/mehmonov/movies-crud/internal/repository"
+)
+
+func testUserService(t *testing.T) *UserService {
+	t.Helper()
+	database := testDatabase(t, config.Default())
+	return NewUserService(repository.NewGormUserRepository(database))
+}
+
+func TestEnsureAdminCreates(t *testing.T) {
+	service := testUserService(t)
+	if err := service.EnsureAdmin("admin", ""); err == nil {
+		t.Fatal("EnsureAdmin without a password succeeded")
+	}
+	if err := service.EnsureAdmin("admin", "adminpass123"); err != nil {
+		t.Fatal(err)
+	}
+	user, err := service.GetUserByUsername("admin")
+	if err != nil || user.Role != models.RoleAdmin {
+		t.Fatalf("got %+v, %v; want an admin", user, err)
+	}
+	// Once there is an admin, EnsureAdmin leaves everyone alone.
+	if err := service.EnsureAdmin("someone", ""); err != nil {
+		t.Errorf("EnsureAdmin with an admin: %v", err)
+	}
+}
+
+func TestEnsureAdminPromotes(t *testing.T) {
+	service := testUserService(t)
+	if _, err := service.CreateUser(&models.CreateUserRequest{Username: "admin", Password: "adminpass123"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.EnsureAdmin("admin", "adminpass123"); err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := service.GetUserByUsername("admin"); user.Role != models.RoleAdmin {
+		t.Errorf("role = %q, want admin", user.Role)
+	}
+}
+
+func TestEnsureAdminRefusesSquatter(t *testing.T) {
+	service := testUserService(t)
+	if _, err := service.CreateUser(&models.CreateUserRequest{Username: "admin", Password: "squatter123"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.EnsureAdmin("admin", "adminpass123"); !errors.Is(err, ErrAdminPasswordMismatch) {
+		t.Fatalf("got %v, want ErrAdminPasswordMismatch", err)
+	}
+	if user, _ := service.GetUserByUsername("admin"); user.Role != models.RoleViewer {
+		t.Errorf("role = %q, want the squatter left a viewer", user.Role)
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mehmonov/movies-crud/internal/models"
+)
+
+var (
+	ErrWatchlistItemExists   = errors.New("movie is already on your watchlist")
+	ErrWatchlistItemNotFound = errors.New("movie is not on your watchlist")
+	ErrInvalidWatchlistOrder = errors.New("movie_ids must list every movie on your watchlist exactly once")
+)
+
+type WatchlistService struct {
+	db *gorm.DB
+}
+
+func NewWatchlistService(db *gorm.DB) *WatchlistService {
+	return &WatchlistService{db: db}
+}
+
+// GetWatchlist returns the user's watchlist in order, skipping deleted movies.
+func (s *WatchlistService) GetWatchlist(userID uint) ([]models.WatchlistItem, error) {
+	items := []models.WatchlistItem{}
+	result := s.db.
+		InnerJoins("Movie").
+		Where("watchlist_items.user_id = ?", userID).
+		Order("watchlist_items.position ASC, watchlist_items.id ASC").
+		Find(&items)
+	return items, result.Error
+}
+
+// AddToWatchlist appends the movie to the end of the user's watchlist.
+func (s *WatchlistService) AddToWatchlist(userID, movieID uint) (*models.WatchlistItem, error) {
+	item := models.WatchlistItem{
+		UserID:  userID,
+		MovieID: movieID,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockUser(tx, userID); err != nil {
+			return err
+		}
+		if err := ensureMovieExists(tx, movieID); err != nil {
+			return err
+		}
+
+		var count int64
+		err := tx.Model(&models.WatchlistItem{}).
+			Where("user_id = ? AND movie_id = ?", userID, movieID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrWatchlistItemExists
+		}
+
+		var last struct{ Position *int }
+		err = tx.Model(&models.WatchlistItem{}).
+			Select("MAX(position) AS position").
+			Where("user_id = ?", userID).
+			Scan(&last).Error
+		if err != nil {
+			return err
+		}
+		if last.Position != nil {
+			item.Position = *last.Position + 1
+		}
+
+		return tx.Omit("Movie", "User").Create(&item).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+func (s *WatchlistService) RemoveFromWatchlist(userID, movieID uint) error {
+	result := s.db.Where("user_id = ? AND movie_id = ?", userID, movieID).Delete(&models.WatchlistItem{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWatchlistItemNotFound
+	}
+	return nil
+}
+
+// ReorderWatchlist puts the user's watchlist in the order of movieIDs, which
+// must name every movie on it exactly once.
+func (s *WatchlistService) ReorderWatchlist(userID uint, movieIDs []uint) ([]models.WatchlistItem, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockUser(tx, userID); err != nil {
+			return err
+		}
+
+		var current []uint
+		err := tx.Model(&models.WatchlistItem{}).
+			Where("user_id = ?", userID).
+			Pluck("movie_id", &current).Error
+		if err != nil {
+			return err
+		}
+
+		if len(current) != len(movieIDs) {
+			return ErrInvalidWatchlistOrder
+		}
+		positions := make(map[uint]int, len(movieIDs))
+		for i, id := range movieIDs {
+			if _, dup := positions[id]; dup {
+				return ErrInvalidWatchlistOrder
+			}
+			positions[id] = i
+		}
+		for _, id := range current {
+			if _, ok := positions[id]; !ok {
+				return ErrInvalidWatchlistOrder
+			}
+		}
+
+		for id, position := range positions {
+			err := tx.Model(&models.WatchlistItem{}).
+				Where("user_id = ? AND movie_id = ?", userID, id).
+				Update("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetWatchlist(userID)
+}
+
+// lockUser takes a row lock on the user for the rest of the transaction so
+// that concurrent changes to their watchlist are applied one after another.
+func lockUser(tx *gorm.DB, userID uint) error {
+	var user models.User
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, userID).Error
+}
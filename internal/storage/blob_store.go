@@ -0,0 +1,47 @@
+// Package storage keeps uploaded files. Files are addressed by keys such as
+// "movie_1/94ccb9a622fa.mp4", which are slash separated relative paths.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// BlobInfo describes a stored blob.
+type BlobInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Blob is an open stored file. It can seek and read at offsets so that byte
+// ranges can be served and containers parsed without reading everything.
+type Blob interface {
+	io.ReadSeekCloser
+	io.ReaderAt
+	Info() BlobInfo
+}
+
+// BlobStore saves and retrieves blobs by key.
+type BlobStore interface {
+	// Put stores the contents of r under key, replacing any existing blob.
+	// A blob is either written completely or not at all.
+	Put(ctx context.Context, key string, r io.Reader) (BlobInfo, error)
+	// Open returns the blob stored under key, or ErrNotFound.
+	Open(ctx context.Context, key string) (Blob, error)
+	// Stat describes the blob stored under key, or returns ErrNotFound.
+	Stat(ctx context.Context, key string) (BlobInfo, error)
+	// Delete removes the blob stored under key. Deleting a missing blob is
+	// not an error.
+	Delete(ctx context.Context, key string) error
+	// DeleteDir removes every blob whose key starts with dir followed by a
+	// slash, such as all the files of one movie.
+	DeleteDir(ctx context.Context, dir string) error
+}
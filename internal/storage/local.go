@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalBlobStore stores blobs as files below a root directory.
+type LocalBlobStore struct {
+	root string
+}
+
+func NewLocalBlobStore(root string) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalBlobStore{root: root}, nil
+}
+
+func (s *LocalBlobStore) Put(ctx context.Context, key string, r io.Reader) (BlobInfo, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return BlobInfo{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return BlobInfo{}, err
+	}
+
+	// Write to a temporary file next to the target and rename it into place
+	// so that readers never see a partial blob.
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".put-*")
+	if err != nil {
+		return BlobInfo{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, contextReader{ctx, r}); err != nil {
+		tmp.Close()
+		return BlobInfo{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return BlobInfo{}, err
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return BlobInfo{}, err
+	}
+
+	return s.Stat(ctx, key)
+}
+
+func (s *LocalBlobStore) Open(ctx context.Context, key string) (Blob, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &localBlob{File: f, info: BlobInfo{Key: key, Size: fi.Size(), ModTime: fi.ModTime()}}, nil
+}
+
+func (s *LocalBlobStore) Stat(ctx context.Context, key string) (BlobInfo, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return BlobInfo{}, err
+	}
+
+	fi, err := os.Stat(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return BlobInfo{}, ErrNotFound
+		}
+		return BlobInfo{}, err
+	}
+	return BlobInfo{Key: key, Size: fi.Size(), ModTime: fi.ModTime()}, nil
+}
+
+func (s *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalBlobStore) DeleteDir(ctx context.Context, dir string) error {
+	name, err := s.path(dir)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(name)
+}
+
+// path maps key to a file below the root, rejecting keys that would escape
+// it.
+func (s *LocalBlobStore) path(key string) (string, error) {
+	if key == "" || strings.Contains(key, "\\") || path.IsAbs(key) || path.Clean(key) != key || !fs.ValidPath(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+type localBlob struct {
+	*os.File
+	info BlobInfo
+}
+
+func (b *localBlob) Info() BlobInfo {
+	return b.info
+}
+
+// contextReader stops reading once ctx is done, so that abandoned uploads do
+// not keep writing.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -2,49 +2,135 @@ package auth
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
+// Claims are the values carried by a token issued by JWTService.
+type Claims struct {
+	UserID uint
+	Role   string
+}
+
+// JWTService issues and validates access tokens. It either signs with a
+// shared HMAC secret or, when built with NewJWTServiceWithKeys, with an
+// asymmetric key whose ID is set as the kid header.
 type JWTService struct {
 	secretKey string
+	ttl       time.Duration
+
+	signingKey *Key
+	keys       map[string]*Key
 }
 
-func NewJWTService(secretKey string) *JWTService {
+// NewJWTService returns a JWTService that signs with HS256 using secretKey
+// and whose tokens expire after ttl.
+func NewJWTService(secretKey string, ttl time.Duration) *JWTService {
 	return &JWTService{
 		secretKey: secretKey,
+		ttl:       ttl,
 	}
 }
 
-func (s *JWTService) GenerateToken(userID uint) (string, error) {
+// NewJWTServiceWithKeys returns a JWTService that signs with signingKey and
+// accepts tokens signed by it or by any of verificationKeys. Keeping the
+// previous signing key in verificationKeys for one token lifetime lets keys
+// be rotated without invalidating tokens already issued.
+func NewJWTServiceWithKeys(signingKey *Key, verificationKeys []*Key, ttl time.Duration) (*JWTService, error) {
+	if signingKey.Private == nil {
+		return nil, fmt.Errorf("signing key %s has no private key", signingKey.ID)
+	}
+
+	keys := map[string]*Key{signingKey.ID: signingKey}
+	for _, key := range verificationKeys {
+		keys[key.ID] = key
+	}
+
+	return &JWTService{
+		ttl:        ttl,
+		signingKey: signingKey,
+		keys:       keys,
+	}, nil
+}
+
+// TTL reports how long the tokens generated by s are valid.
+func (s *JWTService) TTL() time.Duration {
+	return s.ttl
+}
+
+func (s *JWTService) GenerateToken(userID uint, role string) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(), 
+		"role":    role,
+		"exp":     time.Now().Add(s.ttl).Unix(),
 		"iat":     time.Now().Unix(),
 	}
 
+	if s.signingKey != nil {
+		token := jwt.NewWithClaims(s.signingKey.Method, claims)
+		token.Header["kid"] = s.signingKey.ID
+		return token.SignedString(s.signingKey.Private)
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	return token.SignedString([]byte(s.secretKey))
 }
 
-func (s *JWTService) ValidateToken(tokenString string) (uint, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+// JWKS returns the public keys that verify tokens issued by s. It is empty
+// when s signs with an HMAC secret, which must never be published.
+func (s *JWTService) JWKS() (JWKSet, error) {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range s.keys {
+		jwk, err := key.JWK()
+		if err != nil {
+			return JWKSet{}, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set, nil
+}
+
+// verificationKey picks the key that must have signed token, rejecting
+// tokens whose algorithm does not match that key.
+func (s *JWTService) verificationKey(token *jwt.Token) (interface{}, error) {
+	if s.signingKey == nil {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
 		return []byte(s.secretKey), nil
-	})
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.Public, nil
+}
+
+func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+	token, err := jwt.Parse(tokenString, s.verificationKey)
 
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID := uint(claims["user_id"].(float64))
-		return userID, nil
+		userID, ok := claims["user_id"].(float64)
+		if !ok {
+			return nil, errors.New("invalid token")
+		}
+		role, _ := claims["role"].(string)
+		return &Claims{UserID: uint(userID), Role: role}, nil
 	}
 
-	return 0, errors.New("invalid token")
+	return nil, errors.New("invalid token")
 }
@@ -0,0 +1,99 @@
+// Package imaging decodes uploaded images and produces resized copies.
+// Images are always re-encoded, which drops EXIF and other metadata; the
+// EXIF orientation of JPEG files is applied to the pixels first so that
+// photos keep their intended rotation.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // registers the WebP decoder
+)
+
+// Output formats. WebP can be decoded but not encoded, so WebP uploads are
+// stored as JPEG.
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format, expected JPEG, PNG or WebP")
+	ErrTooManyPixels     = errors.New("image dimensions are too large")
+	ErrInvalidImage      = errors.New("image data is invalid")
+)
+
+// MaxPixels bounds the width times height of images that are decoded, so
+// that small files that decompress into huge images are rejected.
+const MaxPixels = 50_000_000
+
+// Decode reads a JPEG, PNG or WebP image and returns it upright, together
+// with the format it should be stored in.
+func Decode(data []byte) (image.Image, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedFormat
+	}
+	if format != "jpeg" && format != "png" && format != "webp" {
+		return nil, "", ErrUnsupportedFormat
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return nil, "", ErrTooManyPixels
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil || img.Bounds().Empty() {
+		return nil, "", ErrInvalidImage
+	}
+
+	if format == "png" {
+		return img, FormatPNG, nil
+	}
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+	return img, FormatJPEG, nil
+}
+
+// Resize scales img to width pixels wide, keeping its aspect ratio.
+func Resize(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// Encode writes img in format.
+func Encode(w io.Writer, img image.Image, format string) error {
+	if format == FormatPNG {
+		return png.Encode(w, img)
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: 88})
+}
+
+// Extension is the file extension used for format.
+func Extension(format string) string {
+	if format == FormatPNG {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// ContentType is the MIME type of format.
+func ContentType(format string) string {
+	if format == FormatPNG {
+		return "image/png"
+	}
+	return "image/jpeg"
+}
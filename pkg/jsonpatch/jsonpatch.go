@@ -0,0 +1,398 @@
+// Package jsonpatch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON documents.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch is returned for patches that are not well-formed.
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrConflict is returned when a JSON Patch cannot be applied to the
+	// document: a path does not exist or a test operation fails.
+	ErrConflict = errors.New("patch cannot be applied")
+)
+
+// MergePatch applies the JSON Merge Patch patch to doc: the members of an
+// object in patch replace those of doc, recursively, and null members remove
+// them. A patch that is not an object replaces the whole document.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(merge(target, changes))
+}
+
+func merge(target, patch interface{}) interface{} {
+	changes, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	object, ok := target.(map[string]interface{})
+	if !ok {
+		object = map[string]interface{}{}
+	}
+	for name, value := range changes {
+		if value == nil {
+			delete(object, name)
+		} else {
+			object[name] = merge(object[name], value)
+		}
+	}
+	return object
+}
+
+// operation is one step of a JSON Patch. Value is kept raw so that a
+// missing value can be told from null.
+type operation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Apply applies the JSON Patch patch, a list of add, remove, replace, move,
+// copy and test operations, to doc. The operations are applied in order and
+// the patch fails as a whole if any of them does.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var ops []operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: a JSON Patch must be an array of operations", ErrInvalidPatch)
+	}
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		target, err = apply(target, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i, op.Op, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func apply(doc interface{}, op operation) (interface{}, error) {
+	if op.Path == nil {
+		return nil, fmt.Errorf("%w: path is missing", ErrInvalidPatch)
+	}
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: value is missing", ErrInvalidPatch)
+		}
+		value, err := decode(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			if doc, _, err = remove(doc, path); err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		default:
+			current, ok := get(doc, path)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s does not exist", ErrConflict, path)
+			}
+			if !equal(current, value) {
+				return nil, fmt.Errorf("%w: %s is not the tested value", ErrConflict, path)
+			}
+			return doc, nil
+		}
+
+	case "remove":
+		doc, _, err = remove(doc, path)
+		return doc, err
+
+	case "move", "copy":
+		if op.From == nil {
+			return nil, fmt.Errorf("%w: from is missing", ErrInvalidPatch)
+		}
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if op.Op == "move" {
+			if from.contains(path) {
+				return nil, fmt.Errorf("%w: cannot move %s into itself", ErrConflict, from)
+			}
+			if doc, value, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			current, ok := get(doc, from)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s does not exist", ErrConflict, from)
+			}
+			value = clone(current)
+		}
+		return add(doc, path, value)
+
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// pointer is a parsed JSON Pointer (RFC 6901); the empty pointer is the
+// whole document.
+type pointer []string
+
+func parsePointer(s string) (pointer, error) {
+	if s == "" {
+		return pointer{}, nil
+	}
+	if s[0] != '/' {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, s)
+	}
+	tokens := strings.Split(s[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func (p pointer) String() string {
+	var b strings.Builder
+	for _, token := range p {
+		b.WriteByte('/')
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+	return strconv.Quote(b.String())
+}
+
+// contains reports whether other is a location inside p.
+func (p pointer) contains(other pointer) bool {
+	if len(other) <= len(p) {
+		return false
+	}
+	for i := range p {
+		if p[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// index parses an array index token for an array of length n. With end,
+// "-" and n itself name the position after the last element.
+func index(token string, n int, end bool) (int, error) {
+	if end && token == "-" {
+		return n, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') || token[0] == '+' {
+		return 0, fmt.Errorf("%w: %q is not an array index", ErrConflict, token)
+	}
+	if i > n || (i == n && !end) {
+		return 0, fmt.Errorf("%w: index %d is out of range", ErrConflict, i)
+	}
+	return i, nil
+}
+
+func get(doc interface{}, path pointer) (interface{}, bool) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, false
+			}
+			doc = value
+		case []interface{}:
+			i, err := index(token, len(node), false)
+			if err != nil {
+				return nil, false
+			}
+			doc = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// add sets the member or inserts the array element at path and returns the
+// changed document. The parent of path must exist.
+func add(doc interface{}, path pointer, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token := path[0]
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			node[token] = value
+			return node, nil
+		}
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s does not exist", ErrConflict, pointer{token})
+		}
+		child, err := add(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = child
+		return node, nil
+
+	case []interface{}:
+		i, err := index(token, len(node), len(path) == 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 1 {
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		}
+		child, err := add(node[i], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = child
+		return node, nil
+
+	default:
+		return nil, fmt.Errorf("%w: cannot add %s to a scalar", ErrConflict, path)
+	}
+}
+
+// remove deletes the value at path, which must exist, and returns the
+// changed document and the removed value.
+func remove(doc interface{}, path pointer) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+	token := path[0]
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %s does not exist", ErrConflict, path)
+		}
+		if len(path) == 1 {
+			delete(node, token)
+			return node, child, nil
+		}
+		child, removed, err := remove(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		node[token] = child
+		return node, removed, nil
+
+	case []interface{}:
+		i, err := index(token, len(node), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(path) == 1 {
+			removed := node[i]
+			return append(node[:i], node[i+1:]...), removed, nil
+		}
+		child, removed, err := remove(node[i], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		node[i] = child
+		return node, removed, nil
+
+	default:
+		return nil, nil, fmt.Errorf("%w: %s does not exist", ErrConflict, path)
+	}
+}
+
+// equal compares two decoded values, numbers by value.
+func equal(a, b interface{}) bool {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for name, value := range a {
+			other, ok := b[name]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, errA := a.Float64()
+		y, errB := b.Float64()
+		return errA == nil && errB == nil && x == y
+	default:
+		return a == b
+	}
+}
+
+func clone(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(value))
+		for name, member := range value {
+			object[name] = clone(member)
+		}
+		return object
+	case []interface{}:
+		array := make([]interface{}, len(value))
+		for i, element := range value {
+			array[i] = clone(element)
+		}
+		return array
+	default:
+		return value
+	}
+}
+
+// decode reads a single JSON value, keeping numbers as written.
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return value, nil
+}
@@ -0,0 +1,271 @@
+package jsonpatch
+
+import (
+	"errors"
+	"testing"
+)
+
+// sameJSON reports whether a and b hold the same JSON value.
+func sameJSON(t *testing.T, a, b string) bool {
+	t.Helper()
+	x, err := decode([]byte(a))
+	if err != nil {
+		t.Fatalf("decode %s: %v", a, err)
+	}
+	y, err := decode([]byte(b))
+	if err != nil {
+		t.Fatalf("decode %s: %v", b, err)
+	}
+	return equal(x, y)
+}
+
+type patchTest struct {
+	name, doc, patch string
+	// want is the patched document, or empty when the patch must fail
+	// with err.
+	want string
+	err  error
+}
+
+func runPatchTests(t *testing.T, fn func(doc, patch []byte) ([]byte, error), tests []patchTest) {
+	t.Helper()
+	for _, test := range tests {
+		got, err := fn([]byte(test.doc), []byte(test.patch))
+		if test.want == "" {
+			if !errors.Is(err, test.err) {
+				t.Errorf("%s: got %s, %v; want %v", test.name, got, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !sameJSON(t, string(got), test.want) {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+// TestApplyRFC6902 runs the examples of RFC 6902, Appendix A.
+func TestApplyRFC6902(t *testing.T) {
+	runPatchTests(t, Apply, []patchTest{
+		{name: "A.1 add an object member",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			want:  `{"baz": "qux", "foo": "bar"}`},
+		{name: "A.2 add an array element",
+			doc:   `{"foo": ["bar", "baz"]}`,
+			patch: `[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			want:  `{"foo": ["bar", "qux", "baz"]}`},
+		{name: "A.3 remove an object member",
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "remove", "path": "/baz"}]`,
+			want:  `{"foo": "bar"}`},
+		{name: "A.4 remove an array element",
+			doc:   `{"foo": ["bar", "qux", "baz"]}`,
+			patch: `[{"op": "remove", "path": "/foo/1"}]`,
+			want:  `{"foo": ["bar", "baz"]}`},
+		{name: "A.5 replace a value",
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			want:  `{"baz": "boo", "foo": "bar"}`},
+		{name: "A.6 move a value",
+			doc:   `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			patch: `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			want:  `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`},
+		{name: "A.7 move an array element",
+			doc:   `{"foo": ["all", "grass", "cows", "eat"]}`,
+			patch: `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			want:  `{"foo": ["all", "cows", "eat", "grass"]}`},
+		{name: "A.8 test a value: success",
+			doc:   `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			patch: `[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`,
+			want:  `{"baz": "qux", "foo": ["a", 2, "c"]}`},
+		{name: "A.9 test a value: error",
+			doc:   `{"baz": "qux"}`,
+			patch: `[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			err:   ErrConflict},
+		{name: "A.10 add a nested member object",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			want:  `{"foo": "bar", "child": {"grandchild": {}}}`},
+		{name: "A.11 ignore unrecognized elements",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			want:  `{"foo": "bar", "baz": "qux"}`},
+		{name: "A.12 add to a nonexistent target",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			err:   ErrConflict},
+		// RFC 6902 calls this patch invalid; encoding/json keeps the last
+		// "op", and removing the missing /baz fails.
+		{name: "A.13 invalid JSON Patch document",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux", "op": "remove"}]`,
+			err:   ErrConflict},
+		{name: "A.14 ~ escape ordering",
+			doc:   `{"/": 9, "~1": 10}`,
+			patch: `[{"op": "test", "path": "/~01", "value": 10}]`,
+			want:  `{"/": 9, "~1": 10}`},
+		{name: "A.15 comparing strings and numbers",
+			doc:   `{"/": 9, "~1": 10}`,
+			patch: `[{"op": "test", "path": "/~01", "value": "10"}]`,
+			err:   ErrConflict},
+		{name: "A.16 add an array value",
+			doc:   `{"foo": ["bar"]}`,
+			patch: `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			want:  `{"foo": ["bar", ["abc", "def"]]}`},
+	})
+}
+
+func TestApply(t *testing.T) {
+	runPatchTests(t, Apply, []patchTest{
+		{name: "~0 and ~1 in member names",
+			doc:   `{"a/b": 1, "m~n": 2, "~1": 3}`,
+			patch: `[{"op": "replace", "path": "/a~1b", "value": 4}, {"op": "remove", "path": "/m~0n"}, {"op": "copy", "from": "/~01", "path": "/~0~1"}]`,
+			want:  `{"a/b": 4, "~1": 3, "~/": 3}`},
+		{name: "- appends",
+			doc:   `{"a": [1, 2]}`,
+			patch: `[{"op": "add", "path": "/a/-", "value": 3}, {"op": "copy", "from": "/a/0", "path": "/a/-"}]`,
+			want:  `{"a": [1, 2, 3, 1]}`},
+		{name: "add at the length appends",
+			doc:   `{"a": [1, 2]}`,
+			patch: `[{"op": "add", "path": "/a/2", "value": 3}]`,
+			want:  `{"a": [1, 2, 3]}`},
+		{name: "remove with -",
+			doc:   `{"a": [1, 2]}`,
+			patch: `[{"op": "remove", "path": "/a/-"}]`,
+			err:   ErrConflict},
+		{name: "replace with -",
+			doc:   `{"a": [1, 2]}`,
+			patch: `[{"op": "replace", "path": "/a/-", "value": 3}]`,
+			err:   ErrConflict},
+		{name: "add past the end",
+			doc:   `{"a": [1, 2]}`,
+			patch: `[{"op": "add", "path": "/a/3", "value": 3}]`,
+			err:   ErrConflict},
+		{name: "remove past the end",
+			doc:   `{"a": [1, 2]}`,
+			patch: `[{"op": "remove", "path": "/a/2"}]`,
+			err:   ErrConflict},
+		{name: "negative index",
+			doc:   `{"a": [1, 2]}`,
+			patch: `[{"op": "remove", "path": "/a/-1"}]`,
+			err:   ErrConflict},
+		{name: "index with a leading zero",
+			doc:   `{"a": [1, 2]}`,
+			patch: `[{"op": "remove", "path": "/a/01"}]`,
+			err:   ErrConflict},
+		{name: "test of a missing path",
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "test", "path": "/b", "value": null}]`,
+			err:   ErrConflict},
+		{name: "test compares numbers by value",
+			doc:   `{"a": 1.0, "b": {"c": [1, "x"]}}`,
+			patch: `[{"op": "test", "path": "/a", "value": 1}, {"op": "test", "path": "/b", "value": {"c": [1e0, "x"]}}]`,
+			want:  `{"a": 1.0, "b": {"c": [1, "x"]}}`},
+		{name: "a failed test rejects the whole patch",
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "replace", "path": "/a", "value": 2}, {"op": "test", "path": "/a", "value": 1}]`,
+			err:   ErrConflict},
+		{name: "null value",
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "add", "path": "/b", "value": null}, {"op": "test", "path": "/b", "value": null}]`,
+			want:  `{"a": 1, "b": null}`},
+		{name: "missing value",
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "add", "path": "/b"}]`,
+			err:   ErrInvalidPatch},
+		{name: "missing path",
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "remove"}]`,
+			err:   ErrInvalidPatch},
+		{name: "path without a leading /",
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "remove", "path": "a"}]`,
+			err:   ErrInvalidPatch},
+		{name: "unknown op",
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "increment", "path": "/a"}]`,
+			err:   ErrInvalidPatch},
+		{name: "not an array",
+			doc:   `{"a": 1}`,
+			patch: `{"op": "remove", "path": "/a"}`,
+			err:   ErrInvalidPatch},
+		{name: "move into itself",
+			doc:   `{"a": {"b": 1}}`,
+			patch: `[{"op": "move", "from": "/a", "path": "/a/c"}]`,
+			err:   ErrConflict},
+		{name: "replace the whole document",
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "replace", "path": "", "value": [1]}]`,
+			want:  `[1]`},
+	})
+}
+
+func TestApplyCopyIsDeep(t *testing.T) {
+	got, err := Apply([]byte(`{"a": {"b": 1}}`), []byte(`[{"op": "copy", "from": "/a", "path": "/c"}, {"op": "replace", "path": "/c/b", "value": 2}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameJSON(t, string(got), `{"a": {"b": 1}, "c": {"b": 2}}`) {
+		t.Errorf("got %s, the copy shares its members with the original", got)
+	}
+}
+
+// TestMergePatchRFC7396 runs the examples of RFC 7396, Appendix A.
+func TestMergePatchRFC7396(t *testing.T) {
+	tests := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, test := range tests {
+		got, err := MergePatch([]byte(test.doc), []byte(test.patch))
+		if err != nil {
+			t.Errorf("MergePatch(%s, %s): %v", test.doc, test.patch, err)
+			continue
+		}
+		if !sameJSON(t, string(got), test.want) {
+			t.Errorf("MergePatch(%s, %s) = %s, want %s", test.doc, test.patch, got, test.want)
+		}
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	runPatchTests(t, MergePatch, []patchTest{
+		{name: "null removes a missing member",
+			doc:   `{"a": 1}`,
+			patch: `{"b": null}`,
+			want:  `{"a": 1}`},
+		{name: "null inside an array is kept",
+			doc:   `{"a": 1}`,
+			patch: `{"a": [null, {"b": null}]}`,
+			want:  `{"a": [null, {"b": null}]}`},
+		{name: "numbers keep their precision",
+			doc:   `{"a": 12345678901234567890}`,
+			patch: `{"b": 1}`,
+			want:  `{"a": 12345678901234567890, "b": 1}`},
+		{name: "invalid patch",
+			doc:   `{"a": 1}`,
+			patch: `{"a":`,
+			err:   ErrInvalidPatch},
+		{name: "trailing data",
+			doc:   `{"a": 1}`,
+			patch: `{} {}`,
+			err:   ErrInvalidPatch},
+	})
+}
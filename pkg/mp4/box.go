@@ -0,0 +1,246 @@
+// Package mp4 reads progressive ISO BMFF (MP4) files and rewrites them as
+// fragmented MP4, as used by HLS and DASH. Only the boxes needed to locate
+// samples are interpreted; everything else is copied as is.
+package mp4
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrInvalid     = errors.New("mp4: malformed file")
+	ErrUnsupported = errors.New("mp4: unsupported file")
+)
+
+// containerTypes are the boxes whose payload is a list of child boxes and
+// that have to be descended into to find the sample tables.
+var containerTypes = map[string]bool{
+	"moov": true,
+	"trak": true,
+	"mdia": true,
+	"minf": true,
+	"stbl": true,
+	"edts": true,
+	"dinf": true,
+	"mvex": true,
+}
+
+// Box is an ISO BMFF box. Containers have Children and no Payload.
+type Box struct {
+	Type     string
+	Payload  []byte
+	Children []*Box
+}
+
+// Child returns the first child of type typ, or nil.
+func (b *Box) Child(typ string) *Box {
+	for _, child := range b.Children {
+		if child.Type == typ {
+			return child
+		}
+	}
+	return nil
+}
+
+// Path follows a chain of child types, such as "mdia", "minf", "stbl".
+func (b *Box) Path(types ...string) *Box {
+	for _, typ := range types {
+		if b = b.Child(typ); b == nil {
+			return nil
+		}
+	}
+	return b
+}
+
+// Size is the encoded size of b, including its header.
+func (b *Box) Size() int {
+	size := 8 + len(b.Payload)
+	for _, child := range b.Children {
+		size += child.Size()
+	}
+	return size
+}
+
+// AppendTo appends the encoding of b to buf.
+func (b *Box) AppendTo(buf []byte) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, uint32(b.Size()))
+	buf = append(buf, b.Type...)
+	buf = append(buf, b.Payload...)
+	for _, child := range b.Children {
+		buf = child.AppendTo(buf)
+	}
+	return buf
+}
+
+// parseBoxes decodes the boxes in data, descending into containers.
+func parseBoxes(data []byte) ([]*Box, error) {
+	var boxes []*Box
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, ErrInvalid
+		}
+		size := uint64(binary.BigEndian.Uint32(data))
+		typ := string(data[4:8])
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, ErrInvalid
+			}
+			size = binary.BigEndian.Uint64(data[8:])
+			header = 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return nil, fmt.Errorf("%w: bad size for box %q", ErrInvalid, typ)
+		}
+
+		box := &Box{Type: typ}
+		payload := data[header:size]
+		if containerTypes[typ] {
+			children, err := parseBoxes(payload)
+			if err != nil {
+				return nil, err
+			}
+			box.Children = children
+		} else {
+			box.Payload = payload
+		}
+		boxes = append(boxes, box)
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+// boxHeader is a top level box found while scanning a file.
+type boxHeader struct {
+	Type   string
+	Offset int64
+	Header int64
+	Size   int64
+}
+
+// scanBoxes lists the top level boxes of a file without reading their
+// payloads.
+func scanBoxes(r io.ReaderAt, fileSize int64) ([]boxHeader, error) {
+	var headers []boxHeader
+	var buf [16]byte
+	for offset := int64(0); offset < fileSize; {
+		if fileSize-offset < 8 {
+			return nil, ErrInvalid
+		}
+		if _, err := r.ReadAt(buf[:8], offset); err != nil {
+			return nil, err
+		}
+		h := boxHeader{
+			Type:   string(buf[4:8]),
+			Offset: offset,
+			Header: 8,
+			Size:   int64(binary.BigEndian.Uint32(buf[:4])),
+		}
+		switch h.Size {
+		case 0:
+			h.Size = fileSize - offset
+		case 1:
+			if _, err := r.ReadAt(buf[8:16], offset+8); err != nil {
+				return nil, err
+			}
+			size := binary.BigEndian.Uint64(buf[8:16])
+			if size > uint64(fileSize) {
+				return nil, ErrInvalid
+			}
+			h.Size = int64(size)
+			h.Header = 16
+		}
+		if h.Size < h.Header || h.Size > fileSize-offset {
+			return nil, fmt.Errorf("%w: bad size for box %q", ErrInvalid, h.Type)
+		}
+		headers = append(headers, h)
+		offset += h.Size
+	}
+	return headers, nil
+}
+
+// reader decodes the big-endian fields of a box payload. After the first
+// short read every call returns zero and err is set.
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) take(n int) []byte {
+	if r.err != nil || n > len(r.data) {
+		r.err = ErrInvalid
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *reader) skip(n int) {
+	r.take(n)
+}
+
+func (r *reader) u8() uint8 {
+	if b := r.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) u16() uint16 {
+	if b := r.take(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) u32() uint32 {
+	if b := r.take(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) u64() uint64 {
+	if b := r.take(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+// count reads an entry count and checks that that many entries of
+// entrySize bytes fit in the rest of the payload, so that corrupt counts
+// can not cause huge allocations.
+func (r *reader) count(entrySize int) int {
+	n := r.u32()
+	if r.err == nil && uint64(n)*uint64(entrySize) > uint64(len(r.data)) {
+		r.err = ErrInvalid
+	}
+	if r.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// fullBox builds the payload of a box that starts with a version and flags.
+func fullBox(version uint8, flags uint32, fields ...[]byte) []byte {
+	payload := binary.BigEndian.AppendUint32(nil, uint32(version)<<24|flags&0xffffff)
+	for _, field := range fields {
+		payload = append(payload, field...)
+	}
+	return payload
+}
+
+func be32(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
+
+func be64(v uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, v)
+}
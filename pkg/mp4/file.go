@@ -0,0 +1,477 @@
+package mp4
+
+import (
+	"fmt"
+	"io"
+)
+
+// Sample is one access unit (a video frame or a group of audio frames) of a
+// track. DTS and Duration are in the track's timescale.
+type Sample struct {
+	Offset   int64
+	Size     uint32
+	DTS      uint64
+	Duration uint32
+	// CTO is the composition time offset: the sample is presented at
+	// DTS+CTO.
+	CTO  int32
+	Sync bool
+}
+
+// Track is an audio or video track of a File.
+type Track struct {
+	ID        uint32
+	Timescale uint32
+	// Handler is "vide" for video and "soun" for audio.
+	Handler string
+	Samples []Sample
+
+	trak *Box
+}
+
+// Duration is the decode duration of the whole track, in its timescale.
+func (t *Track) Duration() uint64 {
+	if len(t.Samples) == 0 {
+		return 0
+	}
+	last := t.Samples[len(t.Samples)-1]
+	return last.DTS + uint64(last.Duration)
+}
+
+// File is a parsed progressive MP4 file. Sample data is read from the
+// underlying reader when fragments are written.
+type File struct {
+	r      io.ReaderAt
+	moov   *Box
+	Tracks []*Track
+}
+
+// Open parses the movie header of the MP4 file read from r. Only the audio
+// and video tracks are kept. Files that are already fragmented are not
+// supported.
+func Open(r io.ReaderAt, size int64) (*File, error) {
+	headers, err := scanBoxes(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var moovHeader *boxHeader
+	for i, h := range headers {
+		switch h.Type {
+		case "moov":
+			moovHeader = &headers[i]
+		case "moof":
+			return nil, fmt.Errorf("%w: file is already fragmented", ErrUnsupported)
+		}
+	}
+	if moovHeader == nil {
+		return nil, fmt.Errorf("%w: no moov box", ErrInvalid)
+	}
+	// The movie header only holds sample tables, so a limit protects against
+	// files that claim an absurd size.
+	if moovHeader.Size > 512<<20 {
+		return nil, fmt.Errorf("%w: moov box too large", ErrUnsupported)
+	}
+
+	data := make([]byte, moovHeader.Size-moovHeader.Header)
+	if _, err := r.ReadAt(data, moovHeader.Offset+moovHeader.Header); err != nil {
+		return nil, err
+	}
+	children, err := parseBoxes(data)
+	if err != nil {
+		return nil, err
+	}
+	moov := &Box{Type: "moov", Children: children}
+	if moov.Child("mvex") != nil {
+		return nil, fmt.Errorf("%w: file is already fragmented", ErrUnsupported)
+	}
+
+	f := &File{r: r, moov: moov}
+	for _, trak := range moov.Children {
+		if trak.Type != "trak" {
+			continue
+		}
+		track, err := parseTrack(trak, size)
+		if err != nil {
+			return nil, err
+		}
+		if track.Handler != "vide" && track.Handler != "soun" {
+			continue
+		}
+		for _, sample := range track.Samples {
+			if sample.Offset+int64(sample.Size) > size {
+				return nil, fmt.Errorf("%w: track %d has samples past the end of the file", ErrInvalid, track.ID)
+			}
+		}
+		f.Tracks = append(f.Tracks, track)
+	}
+	if len(f.Tracks) == 0 {
+		return nil, fmt.Errorf("%w: no audio or video tracks", ErrUnsupported)
+	}
+	return f, nil
+}
+
+func parseTrack(trak *Box, fileSize int64) (*Track, error) {
+	track := &Track{trak: trak}
+
+	tkhd := trak.Child("tkhd")
+	mdhd := trak.Path("mdia", "mdhd")
+	hdlr := trak.Path("mdia", "hdlr")
+	stbl := trak.Path("mdia", "minf", "stbl")
+	if tkhd == nil || mdhd == nil || hdlr == nil || stbl == nil {
+		return nil, fmt.Errorf("%w: incomplete trak box", ErrInvalid)
+	}
+
+	r := &reader{data: tkhd.Payload}
+	if r.u8() == 1 {
+		r.skip(3 + 16)
+	} else {
+		r.skip(3 + 8)
+	}
+	track.ID = r.u32()
+
+	m := &reader{data: mdhd.Payload}
+	if m.u8() == 1 {
+		m.skip(3 + 16)
+	} else {
+		m.skip(3 + 8)
+	}
+	track.Timescale = m.u32()
+
+	h := &reader{data: hdlr.Payload}
+	h.skip(8)
+	track.Handler = string(h.take(4))
+
+	if r.err != nil || m.err != nil || h.err != nil || track.Timescale == 0 {
+		return nil, fmt.Errorf("%w: bad track header", ErrInvalid)
+	}
+
+	samples, err := parseSampleTable(stbl, fileSize)
+	if err != nil {
+		return nil, fmt.Errorf("track %d: %w", track.ID, err)
+	}
+	track.Samples = samples
+	return track, nil
+}
+
+// maxSamples caps the samples of a track, enough for 38 hours of video at
+// 60 frames per second.
+const maxSamples = 1 << 23
+
+// parseSampleTable combines the tables of an stbl box into a list of
+// samples.
+func parseSampleTable(stbl *Box, fileSize int64) ([]Sample, error) {
+	if err := checkSampleCount(stbl, fileSize); err != nil {
+		return nil, err
+	}
+	sizes, err := parseSampleSizes(stbl)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]Sample, len(sizes))
+	for i, size := range sizes {
+		samples[i].Size = size
+	}
+
+	if err := parseTimeToSample(stbl, samples); err != nil {
+		return nil, err
+	}
+	if err := parseCompositionOffsets(stbl, samples); err != nil {
+		return nil, err
+	}
+	if err := parseSyncSamples(stbl, samples); err != nil {
+		return nil, err
+	}
+	if err := parseChunkOffsets(stbl, samples); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// checkSampleCount checks the number of samples in the sample size box
+// against the time to sample and chunk tables and against the size of the
+// file, before anything is allocated for them, so that a corrupt file can
+// not make Open allocate more than the file could describe.
+func checkSampleCount(stbl *Box, fileSize int64) error {
+	var n, constant uint64
+	if stsz := stbl.Child("stsz"); stsz != nil {
+		r := &reader{data: stsz.Payload}
+		r.skip(4)
+		constant, n = uint64(r.u32()), uint64(r.u32())
+		if r.err != nil {
+			return ErrInvalid
+		}
+	} else if stz2 := stbl.Child("stz2"); stz2 != nil {
+		r := &reader{data: stz2.Payload}
+		r.skip(8)
+		n = uint64(r.u32())
+		if r.err != nil {
+			return ErrInvalid
+		}
+	} else {
+		return fmt.Errorf("%w: no sample size box", ErrInvalid)
+	}
+	if n > maxSamples {
+		return fmt.Errorf("%w: %d samples in a track", ErrUnsupported, n)
+	}
+	if constant*n > uint64(fileSize) {
+		return fmt.Errorf("%w: samples larger than the file", ErrInvalid)
+	}
+
+	stts := stbl.Child("stts")
+	if stts == nil {
+		return fmt.Errorf("%w: no stts box", ErrInvalid)
+	}
+	r := &reader{data: stts.Payload}
+	r.skip(4)
+	var timed uint64
+	for entries := r.count(8); entries > 0; entries-- {
+		timed += uint64(r.u32())
+		r.skip(4)
+	}
+	if r.err != nil || timed < n {
+		return fmt.Errorf("%w: stts does not cover every sample", ErrInvalid)
+	}
+
+	var chunks uint64
+	if stco := stbl.Child("stco"); stco != nil {
+		r := &reader{data: stco.Payload}
+		r.skip(4)
+		chunks = uint64(r.count(4))
+	} else if co64 := stbl.Child("co64"); co64 != nil {
+		r := &reader{data: co64.Payload}
+		r.skip(4)
+		chunks = uint64(r.count(8))
+	} else {
+		return fmt.Errorf("%w: no chunk offset box", ErrInvalid)
+	}
+	stsc := stbl.Child("stsc")
+	if stsc == nil {
+		return fmt.Errorf("%w: no stsc box", ErrInvalid)
+	}
+	r = &reader{data: stsc.Payload}
+	r.skip(4)
+	type chunkRun struct{ firstChunk, samplesPerChunk uint64 }
+	runs := r.count(12)
+	var chunked uint64
+	var run chunkRun
+	for entry := 0; entry < runs && chunked < n; entry++ {
+		if entry == 0 {
+			run = chunkRun{uint64(r.u32()), uint64(r.u32())}
+			r.skip(4)
+		}
+		next := chunkRun{firstChunk: chunks + 1}
+		if entry+1 < runs {
+			next = chunkRun{uint64(r.u32()), uint64(r.u32())}
+			r.skip(4)
+		}
+		if run.firstChunk < 1 || next.firstChunk < run.firstChunk || next.firstChunk > chunks+1 {
+			return fmt.Errorf("%w: bad stsc entry", ErrInvalid)
+		}
+		// Both factors fit in 32 bits, so the product does not overflow.
+		if samples := (next.firstChunk - run.firstChunk) * run.samplesPerChunk; samples < n-chunked {
+			chunked += samples
+		} else {
+			chunked = n
+		}
+		run = next
+	}
+	if r.err != nil || chunked < n {
+		return fmt.Errorf("%w: chunks do not cover every sample", ErrInvalid)
+	}
+	return nil
+}
+
+func parseSampleSizes(stbl *Box) ([]uint32, error) {
+	if stsz := stbl.Child("stsz"); stsz != nil {
+		r := &reader{data: stsz.Payload}
+		r.skip(4)
+		constant := r.u32()
+		if constant != 0 {
+			// The count has been checked by checkSampleCount.
+			n := r.u32()
+			sizes := make([]uint32, n)
+			for i := range sizes {
+				sizes[i] = constant
+			}
+			return sizes, r.err
+		}
+		n := r.count(4)
+		sizes := make([]uint32, n)
+		for i := range sizes {
+			sizes[i] = r.u32()
+		}
+		return sizes, r.err
+	}
+
+	if stz2 := stbl.Child("stz2"); stz2 != nil {
+		r := &reader{data: stz2.Payload}
+		r.skip(4 + 3)
+		fieldSize := r.u8()
+		if fieldSize != 4 && fieldSize != 8 && fieldSize != 16 {
+			return nil, ErrInvalid
+		}
+		n := r.u32()
+		if r.err != nil || uint64(n)*uint64(fieldSize) > uint64(len(r.data))*8 {
+			return nil, ErrInvalid
+		}
+		sizes := make([]uint32, n)
+		for i := range sizes {
+			switch fieldSize {
+			case 4:
+				b := r.data[i/2]
+				if i%2 == 0 {
+					sizes[i] = uint32(b >> 4)
+				} else {
+					sizes[i] = uint32(b & 0x0f)
+				}
+			case 8:
+				sizes[i] = uint32(r.u8())
+			case 16:
+				sizes[i] = uint32(r.u16())
+			}
+		}
+		return sizes, r.err
+	}
+
+	return nil, fmt.Errorf("%w: no sample size box", ErrInvalid)
+}
+
+func parseTimeToSample(stbl *Box, samples []Sample) error {
+	stts := stbl.Child("stts")
+	if stts == nil {
+		return fmt.Errorf("%w: no stts box", ErrInvalid)
+	}
+
+	r := &reader{data: stts.Payload}
+	r.skip(4)
+	n := r.count(8)
+	i := 0
+	var dts uint64
+	for entry := 0; entry < n; entry++ {
+		count, delta := r.u32(), r.u32()
+		for ; count > 0 && i < len(samples); count-- {
+			samples[i].DTS = dts
+			samples[i].Duration = delta
+			dts += uint64(delta)
+			i++
+		}
+	}
+	if r.err != nil || i != len(samples) {
+		return fmt.Errorf("%w: stts does not cover every sample", ErrInvalid)
+	}
+	return nil
+}
+
+func parseCompositionOffsets(stbl *Box, samples []Sample) error {
+	ctts := stbl.Child("ctts")
+	if ctts == nil {
+		return nil
+	}
+
+	r := &reader{data: ctts.Payload}
+	r.skip(4)
+	n := r.count(8)
+	i := 0
+	for entry := 0; entry < n; entry++ {
+		// Version 0 offsets are unsigned, but encoders commonly write
+		// negative ones anyway, so both versions are read as signed.
+		count, offset := r.u32(), int32(r.u32())
+		for ; count > 0 && i < len(samples); count-- {
+			samples[i].CTO = offset
+			i++
+		}
+	}
+	return r.err
+}
+
+func parseSyncSamples(stbl *Box, samples []Sample) error {
+	stss := stbl.Child("stss")
+	if stss == nil {
+		// Without a sync sample table every sample is a sync sample.
+		for i := range samples {
+			samples[i].Sync = true
+		}
+		return nil
+	}
+
+	r := &reader{data: stss.Payload}
+	r.skip(4)
+	n := r.count(4)
+	for entry := 0; entry < n; entry++ {
+		number := r.u32()
+		if number >= 1 && int(number) <= len(samples) {
+			samples[number-1].Sync = true
+		}
+	}
+	return r.err
+}
+
+func parseChunkOffsets(stbl *Box, samples []Sample) error {
+	var offsets []int64
+	if stco := stbl.Child("stco"); stco != nil {
+		r := &reader{data: stco.Payload}
+		r.skip(4)
+		offsets = make([]int64, r.count(4))
+		for i := range offsets {
+			offsets[i] = int64(r.u32())
+		}
+		if r.err != nil {
+			return r.err
+		}
+	} else if co64 := stbl.Child("co64"); co64 != nil {
+		r := &reader{data: co64.Payload}
+		r.skip(4)
+		offsets = make([]int64, r.count(8))
+		for i := range offsets {
+			offsets[i] = int64(r.u64())
+			if offsets[i] < 0 {
+				return ErrInvalid
+			}
+		}
+		if r.err != nil {
+			return r.err
+		}
+	} else {
+		return fmt.Errorf("%w: no chunk offset box", ErrInvalid)
+	}
+
+	stsc := stbl.Child("stsc")
+	if stsc == nil {
+		return fmt.Errorf("%w: no stsc box", ErrInvalid)
+	}
+	type chunkRun struct{ firstChunk, samplesPerChunk uint32 }
+	r := &reader{data: stsc.Payload}
+	r.skip(4)
+	runs := make([]chunkRun, r.count(12))
+	for i := range runs {
+		runs[i] = chunkRun{firstChunk: r.u32(), samplesPerChunk: r.u32()}
+		r.skip(4)
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	i := 0
+	for run := range runs {
+		last := uint32(len(offsets))
+		if run+1 < len(runs) {
+			last = runs[run+1].firstChunk - 1
+		}
+		if runs[run].firstChunk < 1 || last > uint32(len(offsets)) {
+			return fmt.Errorf("%w: bad stsc entry", ErrInvalid)
+		}
+		for chunk := runs[run].firstChunk; chunk <= last; chunk++ {
+			offset := offsets[chunk-1]
+			for n := uint32(0); n < runs[run].samplesPerChunk && i < len(samples); n++ {
+				samples[i].Offset = offset
+				offset += int64(samples[i].Size)
+				i++
+			}
+		}
+	}
+	if i != len(samples) {
+		return fmt.Errorf("%w: chunks do not cover every sample", ErrInvalid)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package mp4
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// sampleTable describes the stbl box of a test file.
+type sampleTable struct {
+	stsz, stts, stsc, stco []byte
+}
+
+// testFile builds an MP4 file with one video track described by table,
+// followed by an mdat box of mdatSize bytes.
+func testFile(table sampleTable, mdatSize int) []byte {
+	stbl := &Box{Type: "stbl", Children: []*Box{
+		{Type: "stts", Payload: table.stts},
+		{Type: "stsc", Payload: table.stsc},
+		{Type: "stsz", Payload: table.stsz},
+		{Type: "stco", Payload: table.stco},
+	}}
+	trak := &Box{Type: "trak", Children: []*Box{
+		{Type: "tkhd", Payload: fullBox(0, 0, be32(0), be32(0), be32(1), make([]byte, 68))},
+		{Type: "mdia", Children: []*Box{
+			{Type: "mdhd", Payload: fullBox(0, 0, be32(0), be32(0), be32(1000), be32(0))},
+			{Type: "hdlr", Payload: fullBox(0, 0, be32(0), []byte("vide"), make([]byte, 13))},
+			{Type: "minf", Children: []*Box{stbl}},
+		}},
+	}}
+	moov := &Box{Type: "moov", Children: []*Box{trak}}
+	file := (&Box{Type: "ftyp", Payload: []byte("isom\x00\x00\x02\x00")}).AppendTo(nil)
+	file = moov.AppendTo(file)
+	return (&Box{Type: "mdat", Payload: make([]byte, mdatSize)}).AppendTo(file)
+}
+
+func TestOpenSampleTable(t *testing.T) {
+	// Three samples of 10 bytes in two chunks, the first holding two.
+	table := sampleTable{
+		stsz: fullBox(0, 0, be32(10), be32(3)),
+		stts: fullBox(0, 0, be32(1), be32(3), be32(40)),
+		stsc: fullBox(0, 0, be32(2), be32(1), be32(2), be32(1), be32(2), be32(1), be32(1)),
+	}
+	mdat := int64(len(testFile(table, 0)))
+	table.stco = fullBox(0, 0, be32(2), be32(uint32(mdat+16)), be32(uint32(mdat+36)))
+	data := testFile(table, 64)
+
+	f, err := Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := f.Tracks[0].Samples
+	if len(samples) != 3 {
+		t.Fatalf("got %d samples, want 3", len(samples))
+	}
+	for i, want := range []int64{mdat + 16, mdat + 26, mdat + 36} {
+		if samples[i].Offset != want || samples[i].DTS != uint64(40*i) {
+			t.Errorf("sample %d at offset %d, DTS %d; want %d, %d", i, samples[i].Offset, samples[i].DTS, want, 40*i)
+		}
+	}
+}
+
+func TestOpenMalformedSampleTable(t *testing.T) {
+	oneChunk := fullBox(0, 0, be32(1), be32(1), be32(1), be32(1))
+	tests := []struct {
+		name  string
+		table sampleTable
+	}{{
+		// A constant sample size with a huge count used to make Open
+		// allocate gigabytes for a file of a few hundred bytes.
+		name: "huge constant size count",
+		table: sampleTable{
+			stsz: fullBox(0, 0, be32(1), be32(0xffffffff)),
+			stts: fullBox(0, 0, be32(1), be32(0xffffffff), be32(1)),
+			stsc: oneChunk,
+			stco: fullBox(0, 0, be32(1), be32(0)),
+		},
+	}, {
+		name: "samples larger than the file",
+		table: sampleTable{
+			stsz: fullBox(0, 0, be32(1<<20), be32(1000)),
+			stts: fullBox(0, 0, be32(1), be32(1000), be32(1)),
+			stsc: fullBox(0, 0, be32(1), be32(1), be32(1000), be32(1)),
+			stco: fullBox(0, 0, be32(1), be32(0)),
+		},
+	}, {
+		name: "stts shorter than stsz",
+		table: sampleTable{
+			stsz: fullBox(0, 0, be32(1), be32(100)),
+			stts: fullBox(0, 0, be32(1), be32(1), be32(1)),
+			stsc: fullBox(0, 0, be32(1), be32(1), be32(100), be32(1)),
+			stco: fullBox(0, 0, be32(1), be32(0)),
+		},
+	}, {
+		name: "chunks shorter than stsz",
+		table: sampleTable{
+			stsz: fullBox(0, 0, be32(1), be32(100)),
+			stts: fullBox(0, 0, be32(1), be32(100), be32(1)),
+			stsc: oneChunk,
+			stco: fullBox(0, 0, be32(1), be32(0)),
+		},
+	}, {
+		name: "stsc past the chunks",
+		table: sampleTable{
+			stsz: fullBox(0, 0, be32(1), be32(2)),
+			stts: fullBox(0, 0, be32(1), be32(2), be32(1)),
+			stsc: fullBox(0, 0, be32(2), be32(1), be32(1), be32(1), be32(5), be32(1), be32(1)),
+			stco: fullBox(0, 0, be32(1), be32(0)),
+		},
+	}, {
+		name: "stsc count past the box",
+		table: sampleTable{
+			stsz: fullBox(0, 0, be32(1), be32(1)),
+			stts: fullBox(0, 0, be32(1), be32(1), be32(1)),
+			stsc: fullBox(0, 0, be32(0x10000000)),
+			stco: fullBox(0, 0, be32(1), be32(0)),
+		},
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := testFile(test.table, 0)
+			_, err := Open(bytes.NewReader(data), int64(len(data)))
+			if !errors.Is(err, ErrInvalid) && !errors.Is(err, ErrUnsupported) {
+				t.Fatalf("got %v, want a malformed or unsupported file", err)
+			}
+		})
+	}
+}
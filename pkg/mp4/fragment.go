@@ -0,0 +1,233 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// Sample flags written to trun boxes, as defined for trex default sample
+// flags in ISO/IEC 14496-12.
+const (
+	syncSampleFlags    = 0x02000000 // depends on no other sample
+	nonSyncSampleFlags = 0x01010000 // depends on others, not a sync sample
+)
+
+// Segment is a time range of a File that is written as one fragment. Start
+// and End index the samples of each track in the same order as
+// File.Tracks.
+type Segment struct {
+	Duration time.Duration
+	Start    []int
+	End      []int
+}
+
+// InitSegment returns the initialization segment of the fragmented file:
+// an ftyp box and a moov box that describes the tracks but holds no
+// samples.
+func (f *File) InitSegment() []byte {
+	ftyp := &Box{Type: "ftyp", Payload: []byte("iso6\x00\x00\x00\x00iso6cmfcmp41")}
+
+	kept := make(map[*Box]bool, len(f.Tracks))
+	mvex := &Box{Type: "mvex"}
+	for _, track := range f.Tracks {
+		kept[track.trak] = true
+		mvex.Children = append(mvex.Children, &Box{
+			Type:    "trex",
+			Payload: fullBox(0, 0, be32(track.ID), be32(1), be32(0), be32(0), be32(0)),
+		})
+	}
+
+	moov := &Box{Type: "moov"}
+	for _, child := range f.moov.Children {
+		switch {
+		case child.Type == "trak" && kept[child]:
+			moov.Children = append(moov.Children, emptyTrak(child))
+		case child.Type == "trak":
+			// Tracks other than audio and video are dropped.
+		default:
+			moov.Children = append(moov.Children, child)
+		}
+	}
+	moov.Children = append(moov.Children, mvex)
+
+	return moov.AppendTo(ftyp.AppendTo(nil))
+}
+
+// emptyTrak copies trak with its sample tables emptied, as samples are
+// described by the fragments instead.
+func emptyTrak(trak *Box) *Box {
+	return rebuild(trak, func(stbl *Box) *Box {
+		empty := &Box{Type: "stbl"}
+		if stsd := stbl.Child("stsd"); stsd != nil {
+			empty.Children = append(empty.Children, stsd)
+		}
+		empty.Children = append(empty.Children,
+			&Box{Type: "stts", Payload: fullBox(0, 0, be32(0))},
+			&Box{Type: "stsc", Payload: fullBox(0, 0, be32(0))},
+			&Box{Type: "stsz", Payload: fullBox(0, 0, be32(0), be32(0))},
+			&Box{Type: "stco", Payload: fullBox(0, 0, be32(0))},
+		)
+		return empty
+	})
+}
+
+// rebuild copies the containers of box down to its stbl box, which is
+// replaced by replace(stbl).
+func rebuild(box *Box, replace func(stbl *Box) *Box) *Box {
+	if box.Type == "stbl" {
+		return replace(box)
+	}
+	if box.Children == nil {
+		return box
+	}
+	copied := &Box{Type: box.Type}
+	for _, child := range box.Children {
+		copied.Children = append(copied.Children, rebuild(child, replace))
+	}
+	return copied
+}
+
+// Segments splits the file into segments of about target duration. Each
+// segment starts on a sync sample of the first video track, or of the first
+// track if there is no video, so that it can be decoded on its own.
+func (f *File) Segments(target time.Duration) []Segment {
+	ref := 0
+	for i, track := range f.Tracks {
+		if track.Handler == "vide" {
+			ref = i
+			break
+		}
+	}
+	refTrack := f.Tracks[ref]
+	refScale := uint64(refTrack.Timescale)
+	targetTicks := uint64(target.Seconds() * float64(refScale))
+
+	// Find the decode times at which segments start.
+	var starts []uint64
+	for _, sample := range refTrack.Samples {
+		if len(starts) == 0 || sample.Sync && sample.DTS-starts[len(starts)-1] >= targetTicks {
+			starts = append(starts, sample.DTS)
+		}
+	}
+	if len(starts) == 0 {
+		return nil
+	}
+
+	segments := make([]Segment, len(starts))
+	cursors := make([]int, len(f.Tracks))
+	for i := range segments {
+		last := i == len(segments)-1
+		end := refTrack.Duration()
+		if !last {
+			end = starts[i+1]
+		}
+
+		segment := Segment{
+			Duration: time.Duration(float64(end-starts[i]) / float64(refScale) * float64(time.Second)),
+			Start:    make([]int, len(f.Tracks)),
+			End:      make([]int, len(f.Tracks)),
+		}
+		for t, track := range f.Tracks {
+			segment.Start[t] = cursors[t]
+			if last {
+				cursors[t] = len(track.Samples)
+			} else {
+				// Compare dts/timescale < end/refScale without dividing.
+				scale := uint64(track.Timescale)
+				for cursors[t] < len(track.Samples) && track.Samples[cursors[t]].DTS*refScale < end*scale {
+					cursors[t]++
+				}
+			}
+			segment.End[t] = cursors[t]
+		}
+		segments[i] = segment
+	}
+	return segments
+}
+
+// WriteSegment writes segment as a moof and mdat box pair with the given
+// sequence number, which starts at 1.
+func (f *File) WriteSegment(w io.Writer, sequence uint32, segment Segment) error {
+	var dataSize int64
+	for t, track := range f.Tracks {
+		for _, sample := range track.Samples[segment.Start[t]:segment.End[t]] {
+			dataSize += int64(sample.Size)
+		}
+	}
+
+	header := binary.BigEndian.AppendUint32(nil, uint32(dataSize+8))
+	header = append(header, "mdat"...)
+	if dataSize+8 > 1<<32-1 {
+		header = binary.BigEndian.AppendUint32(nil, 1)
+		header = append(header, "mdat"...)
+		header = binary.BigEndian.AppendUint64(header, uint64(dataSize+16))
+	}
+
+	// The data offsets in the trun boxes depend on the size of the moof box,
+	// which does not depend on their values, so build it once to size it.
+	moof := f.buildMoof(sequence, segment, 0)
+	moof = f.buildMoof(sequence, segment, int64(moof.Size()+len(header)))
+
+	if _, err := w.Write(moof.AppendTo(nil)); err != nil {
+		return err
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	for t, track := range f.Tracks {
+		samples := track.Samples[segment.Start[t]:segment.End[t]]
+		// Copy runs of samples that are contiguous in the file at once.
+		for i := 0; i < len(samples); {
+			start, size := samples[i].Offset, int64(samples[i].Size)
+			for i++; i < len(samples) && samples[i].Offset == start+size; i++ {
+				size += int64(samples[i].Size)
+			}
+			if _, err := io.Copy(w, io.NewSectionReader(f.r, start, size)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// buildMoof builds the moof box of a segment. dataOffset is where the
+// sample data starts relative to the moof box, that is after the moof box
+// and the mdat header.
+func (f *File) buildMoof(sequence uint32, segment Segment, dataOffset int64) *Box {
+	moof := &Box{Type: "moof"}
+	moof.Children = append(moof.Children, &Box{Type: "mfhd", Payload: fullBox(0, 0, be32(sequence))})
+
+	for t, track := range f.Tracks {
+		samples := track.Samples[segment.Start[t]:segment.End[t]]
+		if len(samples) == 0 {
+			continue
+		}
+
+		trun := fullBox(1, 0x000f01, be32(uint32(len(samples))), be32(uint32(dataOffset)))
+		trun = append(make([]byte, 0, len(trun)+16*len(samples)), trun...)
+		for _, sample := range samples {
+			flags := uint32(nonSyncSampleFlags)
+			if sample.Sync {
+				flags = syncSampleFlags
+			}
+			trun = binary.BigEndian.AppendUint32(trun, sample.Duration)
+			trun = binary.BigEndian.AppendUint32(trun, sample.Size)
+			trun = binary.BigEndian.AppendUint32(trun, flags)
+			trun = binary.BigEndian.AppendUint32(trun, uint32(sample.CTO))
+			dataOffset += int64(sample.Size)
+		}
+
+		moof.Children = append(moof.Children, &Box{
+			Type: "traf",
+			Children: []*Box{
+				// Offsets are relative to the start of the moof box.
+				{Type: "tfhd", Payload: fullBox(0, 0x020000, be32(track.ID))},
+				{Type: "tfdt", Payload: fullBox(1, 0, be64(samples[0].DTS))},
+				{Type: "trun", Payload: trun},
+			},
+		})
+	}
+	return moof
+}
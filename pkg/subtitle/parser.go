@@ -0,0 +1,213 @@
+package subtitle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type parser struct {
+	lines  []string
+	cues   []Cue
+	errors ErrorList
+}
+
+func (p *parser) errorf(line int, format string, args ...interface{}) {
+	if len(p.errors) < maxErrors {
+		p.errors = append(p.errors, &Error{Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// blocks calls fn with each run of non-blank lines from line start on, and
+// the line number of its first line.
+func (p *parser) blocks(start int, fn func(block []string, line int)) {
+	for i := start; i < len(p.lines); {
+		if strings.TrimSpace(p.lines[i]) == "" {
+			i++
+			continue
+		}
+		end := i
+		for end < len(p.lines) && strings.TrimSpace(p.lines[end]) != "" {
+			end++
+		}
+		fn(p.lines[i:end], i+1)
+		i = end
+	}
+}
+
+// parseSRT reads SubRip cues: a sequence number, a timing line such as
+// "00:00:01,000 --> 00:00:02,500" and one or more lines of text.
+func (p *parser) parseSRT() {
+	p.blocks(0, func(block []string, line int) {
+		id := ""
+		if !strings.Contains(block[0], "-->") {
+			id = strings.TrimSpace(block[0])
+			if _, err := strconv.Atoi(id); err != nil {
+				p.errorf(line, "expected a cue number or timing line, found %q", excerpt(block[0]))
+				return
+			}
+			block, line = block[1:], line+1
+			if len(block) == 0 {
+				p.errorf(line-1, "cue %s has no timing line", id)
+				return
+			}
+		}
+		p.parseCue(id, block, line, true)
+	})
+}
+
+// parseVTT reads a WebVTT file: a "WEBVTT" header followed by cues, each
+// with an optional identifier, a timing line with optional settings and its
+// text. NOTE, STYLE and REGION blocks are skipped.
+func (p *parser) parseVTT() {
+	// The header runs up to the first blank line.
+	start := 0
+	for start < len(p.lines) && strings.TrimSpace(p.lines[start]) != "" {
+		start++
+	}
+
+	p.blocks(start, func(block []string, line int) {
+		first := block[0]
+		for _, keyword := range []string{"NOTE", "STYLE", "REGION"} {
+			if first == keyword || strings.HasPrefix(first, keyword+" ") || strings.HasPrefix(first, keyword+"\t") {
+				return
+			}
+		}
+
+		id := ""
+		if !strings.Contains(first, "-->") {
+			id = first
+			block, line = block[1:], line+1
+			if len(block) == 0 || !strings.Contains(block[0], "-->") {
+				p.errorf(line, "expected a cue timing line after identifier %q", excerpt(id))
+				return
+			}
+		}
+		p.parseCue(id, block, line, false)
+	})
+}
+
+// parseCue reads a timing line and the text lines after it.
+func (p *parser) parseCue(id string, block []string, line int, srt bool) {
+	cue := Cue{ID: id}
+
+	parts := strings.SplitN(block[0], "-->", 2)
+	if len(parts) != 2 {
+		p.errorf(line, "expected a cue timing line like %s, found %q", exampleTiming(srt), excerpt(block[0]))
+		return
+	}
+	fields := strings.Fields(parts[1])
+	if len(fields) == 0 {
+		p.errorf(line, "cue timing line has no end time")
+		return
+	}
+
+	var err error
+	if cue.Start, err = parseTimestamp(strings.TrimSpace(parts[0]), srt); err != nil {
+		p.errorf(line, "invalid start time: %v", err)
+		return
+	}
+	if cue.End, err = parseTimestamp(fields[0], srt); err != nil {
+		p.errorf(line, "invalid end time: %v", err)
+		return
+	}
+	if cue.End <= cue.Start {
+		p.errorf(line, "cue ends at %s, not after its start at %s", formatTimestamp(cue.End), formatTimestamp(cue.Start))
+		return
+	}
+	// SRT files sometimes carry pixel coordinates after the end time, which
+	// have no WebVTT equivalent.
+	if !srt {
+		cue.Settings = strings.Join(fields[1:], " ")
+	}
+
+	if len(block) < 2 {
+		p.errorf(line, "cue has no text")
+		return
+	}
+	cue.Text = strings.Join(block[1:], "\n")
+	p.cues = append(p.cues, cue)
+}
+
+// parseTimestamp parses "hh:mm:ss,mmm" (SRT, where "." is accepted too) or
+// "[hh:]mm:ss.mmm" (WebVTT).
+func parseTimestamp(s string, srt bool) (time.Duration, error) {
+	separator := strings.LastIndexAny(s, ",.")
+	if separator < 0 {
+		return 0, fmt.Errorf("%q has no milliseconds", s)
+	}
+	if !srt && s[separator] == ',' {
+		return 0, fmt.Errorf("%q uses a comma, WebVTT needs a period before the milliseconds", s)
+	}
+
+	millis := s[separator+1:]
+	parts := strings.Split(s[:separator], ":")
+	if len(millis) != 3 || len(parts) < 2 || len(parts) > 3 || srt && len(parts) != 3 {
+		return 0, fmt.Errorf("%q is not of the form %s", s, exampleTimestamp(srt))
+	}
+
+	var hours, minutes, seconds int
+	var err error
+	if len(parts) == 3 {
+		if hours, err = digits(parts[0], 2, 0); err != nil {
+			return 0, fmt.Errorf("%q: hours %v", s, err)
+		}
+		parts = parts[1:]
+	}
+	if minutes, err = digits(parts[0], 2, 59); err != nil {
+		return 0, fmt.Errorf("%q: minutes %v", s, err)
+	}
+	if seconds, err = digits(parts[1], 2, 59); err != nil {
+		return 0, fmt.Errorf("%q: seconds %v", s, err)
+	}
+	ms, err := digits(millis, 3, 999)
+	if err != nil {
+		return 0, fmt.Errorf("%q: milliseconds %v", s, err)
+	}
+
+	return time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds)*time.Second +
+		time.Duration(ms)*time.Millisecond, nil
+}
+
+// digits parses a number of at least minLen digits that is at most max, or
+// of any size when max is 0.
+func digits(s string, minLen, max int) (int, error) {
+	if len(s) < minLen || len(s) > 9 {
+		return 0, fmt.Errorf("must have at least %d digits", minLen)
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("must only contain digits")
+		}
+	}
+	n, _ := strconv.Atoi(s)
+	if max > 0 && n > max {
+		return 0, fmt.Errorf("must be at most %d", max)
+	}
+	return n, nil
+}
+
+func exampleTimestamp(srt bool) string {
+	if srt {
+		return "00:01:02,500"
+	}
+	return "00:01:02.500"
+}
+
+func exampleTiming(srt bool) string {
+	if srt {
+		return "00:00:01,000 --> 00:00:02,500"
+	}
+	return "00:00:01.000 --> 00:00:02.500"
+}
+
+// excerpt shortens a line quoted in an error message.
+func excerpt(line string) string {
+	if runes := []rune(line); len(runes) > 40 {
+		return string(runes[:40]) + "..."
+	}
+	return line
+}
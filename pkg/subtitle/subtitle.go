@@ -0,0 +1,133 @@
+// Package subtitle parses SRT and WebVTT subtitles and writes WebVTT.
+package subtitle
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	FormatSRT = "srt"
+	FormatVTT = "vtt"
+)
+
+// Cue is one subtitle shown from Start to End.
+type Cue struct {
+	ID    string
+	Start time.Duration
+	End   time.Duration
+	// Settings are the WebVTT cue settings, such as "align:start".
+	Settings string
+	Text     string
+}
+
+// Error is a problem found on one line of a subtitle file.
+type Error struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// ErrorList is every problem found in a file, in line order.
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	if len(l) == 1 {
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0].Error(), len(l)-1)
+}
+
+// maxErrors stops reporting once a file is clearly not a subtitle file.
+const maxErrors = 50
+
+// Parse detects the format of data, SRT or WebVTT, and parses it. A file
+// with any malformed cue is rejected with an ErrorList.
+func Parse(data []byte) (string, []Cue, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	lines := strings.Split(text, "\n")
+
+	p := &parser{lines: lines}
+	for i, line := range lines {
+		if !utf8.ValidString(line) {
+			p.errorf(i+1, "text is not valid UTF-8")
+		}
+	}
+
+	format := FormatSRT
+	if first := lines[0]; first == "WEBVTT" || strings.HasPrefix(first, "WEBVTT ") || strings.HasPrefix(first, "WEBVTT\t") {
+		format = FormatVTT
+		p.parseVTT()
+	} else {
+		p.parseSRT()
+	}
+
+	if len(p.errors) > 0 {
+		return format, nil, p.errors
+	}
+	if len(p.cues) == 0 {
+		return format, nil, ErrorList{{Line: 1, Message: "file contains no cues"}}
+	}
+	return format, p.cues, nil
+}
+
+// Shift moves every cue by offset. Cues that would end before zero are
+// dropped and cues that would start before zero start at zero.
+func Shift(cues []Cue, offset time.Duration) []Cue {
+	shifted := make([]Cue, 0, len(cues))
+	for _, cue := range cues {
+		cue.Start += offset
+		cue.End += offset
+		if cue.End <= 0 {
+			continue
+		}
+		if cue.Start < 0 {
+			cue.Start = 0
+		}
+		shifted = append(shifted, cue)
+	}
+	return shifted
+}
+
+// WriteVTT encodes cues as a WebVTT file.
+func WriteVTT(cues []Cue) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("WEBVTT\n")
+	for _, cue := range cues {
+		buf.WriteString("\n")
+		if cue.ID != "" {
+			buf.WriteString(cue.ID + "\n")
+		}
+		buf.WriteString(formatTimestamp(cue.Start) + " --> " + formatTimestamp(cue.End))
+		if cue.Settings != "" {
+			buf.WriteString(" " + cue.Settings)
+		}
+		buf.WriteString("\n" + vttText(cue.Text) + "\n")
+	}
+	return buf.Bytes()
+}
+
+// fontTag matches the opening and closing <font> tags common in SRT files,
+// in any case.
+var fontTag = regexp.MustCompile(`(?i)</?font\b[^>]*>`)
+
+// vttText adapts cue text for WebVTT, which does not allow "-->" in cue
+// text nor <font> tags.
+func vttText(text string) string {
+	text = strings.ReplaceAll(text, "-->", "--&gt;")
+	return fontTag.ReplaceAllString(text, "")
+}
+
+func formatTimestamp(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,116 @@
+package subtitle
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSRT(t *testing.T) {
+	data := "\xef\xbb\xbf1\r\n00:00:01,000 --> 00:00:02,500\r\nHello\r\nthere\r\n\r\n" +
+		"2\n00:01:02.250 --> 01:00:00,000 X1:10 X2:20\n<i>Bye</i>\n"
+	format, cues, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cue{
+		{ID: "1", Start: time.Second, End: 2500 * time.Millisecond, Text: "Hello\nthere"},
+		{ID: "2", Start: time.Minute + 2250*time.Millisecond, End: time.Hour, Text: "<i>Bye</i>"},
+	}
+	if format != FormatSRT || !reflect.DeepEqual(cues, want) {
+		t.Errorf("Parse = %s, %+v; want srt, %+v", format, cues, want)
+	}
+}
+
+func TestParseVTT(t *testing.T) {
+	data := "WEBVTT - demo\nKind: captions\n\nNOTE a comment\n\nSTYLE\n::cue { color: red }\n\n" +
+		"intro\n00:01.000 --> 00:02.000 align:start line:0\nHi\n\n01:00:00.000 --> 01:00:01.500\nBye\n"
+	format, cues, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cue{
+		{ID: "intro", Start: time.Second, End: 2 * time.Second, Settings: "align:start line:0", Text: "Hi"},
+		{Start: time.Hour, End: time.Hour + 1500*time.Millisecond, Text: "Bye"},
+	}
+	if format != FormatVTT || !reflect.DeepEqual(cues, want) {
+		t.Errorf("Parse = %s, %+v; want vtt, %+v", format, cues, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name, data string
+		line       int
+		message    string
+	}{
+		{"empty", "", 1, "no cues"},
+		{"no cue number", "one\n00:00:01,000 --> 00:00:02,000\nHi\n", 1, "cue number"},
+		{"no timing", "1\n\n", 1, "no timing line"},
+		{"missing timing line", "1\nHi\n", 2, "timing line"},
+		{"srt with a period-less time", "1\n00:00:01 --> 00:00:02,000\nHi\n", 2, "start time"},
+		{"vtt with a comma", "WEBVTT\n\n00:00:01,000 --> 00:00:02.000\nHi\n", 3, "comma"},
+		{"minutes out of range", "1\n00:60:01,000 --> 01:00:02,000\nHi\n", 2, "at most 59"},
+		{"ends before it starts", "1\n00:00:02,000 --> 00:00:01,000\nHi\n", 2, "not after its start"},
+		{"no text", "1\n00:00:01,000 --> 00:00:02,000\n", 2, "no text"},
+		{"invalid utf-8", "1\n00:00:01,000 --> 00:00:02,000\n\xff\n", 3, "UTF-8"},
+	}
+	for _, test := range tests {
+		_, _, err := Parse([]byte(test.data))
+		var list ErrorList
+		if !errors.As(err, &list) {
+			t.Errorf("%s: got %v, want an ErrorList", test.name, err)
+			continue
+		}
+		if list[0].Line != test.line || !strings.Contains(list[0].Message, test.message) {
+			t.Errorf("%s: got %v, want line %d about %q", test.name, list[0], test.line, test.message)
+		}
+	}
+}
+
+func TestShift(t *testing.T) {
+	cues := []Cue{
+		{Start: 0, End: time.Second},
+		{Start: time.Second, End: 3 * time.Second},
+		{Start: 3 * time.Second, End: 4 * time.Second},
+	}
+	got := Shift(cues, -2*time.Second)
+	want := []Cue{{Start: 0, End: time.Second}, {Start: time.Second, End: 2 * time.Second}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Shift = %+v, want %+v", got, want)
+	}
+}
+
+func TestWriteVTT(t *testing.T) {
+	cues := []Cue{
+		{ID: "1", Start: 1500 * time.Millisecond, End: 2 * time.Hour, Settings: "align:start", Text: "A --> B"},
+		{Start: 0, End: time.Second, Text: `<FONT color="red">red</Font> and <font>plain</font> <fonts>`},
+	}
+	want := "WEBVTT\n\n1\n00:00:01.500 --> 02:00:00.000 align:start\nA --&gt; B\n\n" +
+		"00:00:00.000 --> 00:00:01.000\nred and plain <fonts>\n"
+	if got := string(WriteVTT(cues)); got != want {
+		t.Errorf("WriteVTT =\n%s\nwant\n%s", got, want)
+	}
+
+	_, parsed, err := Parse(WriteVTT(cues))
+	if err != nil || len(parsed) != 2 || parsed[0].Settings != "align:start" {
+		t.Errorf("WriteVTT output does not parse back: %+v, %v", parsed, err)
+	}
+}
+
+func TestVTTTextNonASCII(t *testing.T) {
+	// Lowercasing Ⱥ and İ changes their length in bytes, which used to
+	// misplace the tags and slice past the end of the text.
+	tests := []struct{ text, want string }{
+		{"ȺȺȺȺȺȺȺȺȺȺ<font>", "ȺȺȺȺȺȺȺȺȺȺ"},
+		{"İİİİİİİİİİ<font color=red>hi</font>", "İİİİİİİİİİhi"},
+		{"<FONT face=x>ÉLAN</FONT> <font", "ÉLAN <font"},
+	}
+	for _, test := range tests {
+		if got := vttText(test.text); got != test.want {
+			t.Errorf("vttText(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}